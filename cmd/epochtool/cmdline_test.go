@@ -2,29 +2,34 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/atotto/clipboard"
 	"github.com/deathbots/epochtool"
 	"strings"
 	"testing"
 	"runtime"
 	"fmt"
+	"time"
 )
 
-var goodParse = []string{"3902432", "4928432432"}
-// this number won't fit in an int64
+// the last is the largest uint64, which is read exactly even though it won't fit in an int64.
+var goodParse = []string{"3902432", "4928432432", "18446744073709551615"}
+// this number is read, but is too large to be a date in any epoch at any resolution.
 var badParse = []string{"3452543252352353253253252"}
+// the numbers are guessed against this, rather than the time the tests are run.
+var testClock = epochconv.FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
 
 func TestClipboardParseGood(t *testing.T) {
 	if clipboard.Unsupported {
 		t.Skipf("Clipboard not supported on OS %s", runtime.GOOS)
 	}
-	strs := make([]string, 0)
+	strs := make([]epochconv.ExtractedNumber, 0)
 	clipboard.WriteAll(strings.Join(goodParse, ", \t"))
 	err := epochStringsFromClipboard(&strs)
 	if err != nil {
 		t.Errorf(err.Error())
 	}
-	epochResults, badStrings, err := epochconv.GuessesForStrings(strs)
+	epochResults, badStrings, _, err := epochconv.NewGuesser(epochconv.WithClock(testClock)).GuessExtracted(strs)
 	if err != nil {
 		t.Errorf("Failure to parse known good numbers - these failed:%v with error: %s", badStrings, err)
 	}
@@ -38,13 +43,13 @@ func TestClipboardParseBad(t *testing.T) {
 	if clipboard.Unsupported {
 		t.Skipf("Clipboard not supported on OS %s", runtime.GOOS)
 	}
-	strs := make([]string, 0)
+	strs := make([]epochconv.ExtractedNumber, 0)
 	clipboard.WriteAll(strings.Join(badParse, ", \t"))
 	err := epochStringsFromClipboard(&strs)
 	if err != nil {
 		t.Errorf(err.Error())
 	}
-	epochResults, badStrings, err := epochconv.GuessesForStrings(strs)
+	epochResults, badStrings, _, err := epochconv.NewGuesser(epochconv.WithClock(testClock)).GuessExtracted(strs)
 	if err == nil {
 		t.Errorf("Should have received error parsing bad string: %s", err)
 	}
@@ -58,9 +63,9 @@ func TestClipboardParseBad(t *testing.T) {
 
 
 func TestCmdLineParseGood(t *testing.T) {
-	strs := make([]string, 0)
+	strs := make([]epochconv.ExtractedNumber, 0)
 	epochStringsFromCommandLine(&strs, goodParse)
-	epochResults, badStrings, err := epochconv.GuessesForStrings(strs)
+	epochResults, badStrings, _, err := epochconv.NewGuesser(epochconv.WithClock(testClock)).GuessExtracted(strs)
 	if err != nil {
 		t.Errorf("Failure to parse known good numbers - these failed:%v with error: %s", badStrings, err)
 	}
@@ -71,12 +76,16 @@ func TestCmdLineParseGood(t *testing.T) {
 }
 
 func TestCmdLineParseBad(t *testing.T) {
-	strs := make([]string, 0)
+	strs := make([]epochconv.ExtractedNumber, 0)
 	epochStringsFromCommandLine(&strs, badParse)
-	epochResults, badStrings, err := epochconv.GuessesForStrings(strs)
+	epochResults, badStrings, _, err := epochconv.NewGuesser(epochconv.WithClock(testClock)).GuessExtracted(strs)
 	if err == nil {
 		t.Errorf("Should have received error parsing bad string: %s", err)
 	}
+	var parseErr *epochconv.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Failures[0].Reason != epochconv.ParseOutOfRange {
+		t.Errorf("Should have failed as out of range, got %v", err)
+	}
 	if len(badStrings) != len(badParse) {
 		t.Errorf("Should have failed to parse %d numbers but failed to parse %d", len(badParse), len(badStrings))
 	}
@@ -85,11 +94,13 @@ func TestCmdLineParseBad(t *testing.T) {
 	}
 }
 
-// this number is so high, it will always produce common era, the oldest epoch
-var commonEraHighInt = []string{"9223346836"}
+// this number is so high, it will always produce common era, the oldest epoch. It is 2025 in common era seconds,
+// and years away from it at every other resolution.
+var commonEraHighInt = []string{"63900000000"}
 
 func TestJsonParse(t *testing.T) {
-	epochResults, badStrings, err := epochconv.GuessesForStrings(commonEraHighInt)
+	at := epochconv.FixedClock(time.Date(2025, 10, 9, 0, 0, 0, 0, time.UTC))
+	epochResults, badStrings, _, err := epochconv.NewGuesser(epochconv.WithClock(at)).GuessStrings(commonEraHighInt)
 	if err != nil {
 		stdErr("Could not parse the following input strings")
 		for _, badString := range badStrings {
@@ -107,3 +118,233 @@ func TestJsonParse(t *testing.T) {
 	}
 }
 
+
+var formatDateTests = []struct {
+	in        string
+	precision time.Duration
+	want      string
+}{
+	{"1600000000", time.Second, "2020-09-13T12:26:40Z"},
+	{"1600000000.123456", time.Microsecond, "2020-09-13T12:26:40.123456Z"},
+	{"1600000000.1234567", 100 * time.Nanosecond, "2020-09-13T12:26:40.1234567Z"},
+	{"1600000000.5", 100 * time.Millisecond, "2020-09-13T12:26:40.5Z"},
+}
+
+// Tests whether decimal input is printed with all of its fractional seconds, and no made up digits.
+func TestFormatDatePrecision(t *testing.T) {
+	for _, tt := range formatDateTests {
+		value, err := epochconv.ParseEpochNumber(tt.in)
+		if err != nil {
+			t.Fatalf("Could not parse %s: %s", tt.in, err)
+		}
+		date, err := epochconv.EpochUnix.DateForValue(value, true)
+		if err != nil {
+			t.Fatalf("Could not convert %s: %s", tt.in, err)
+		}
+		if precision := value.Precision(epochconv.UnitSeconds); precision != tt.precision {
+			t.Errorf("%s should be precise to %s, got %s", tt.in, tt.precision, precision)
+		}
+		if got := formatDate(date, tt.precision); got != tt.want {
+			t.Errorf("%s should print as %s, got %s", tt.in, tt.want, got)
+		}
+	}
+}
+
+// Tests whether -tz may be repeated, and each zone is printed with the offset on the date.
+func TestZoneFlag(t *testing.T) {
+	var zones zoneList
+	for _, name := range []string{"America/New_York", "Europe/London"} {
+		if err := zones.Set(name); err != nil {
+			t.Fatalf("Could not set -tz %s: %s", name, err)
+		}
+	}
+	if err := zones.Set("Not/AZone"); err == nil {
+		t.Error("An unknown zone should be an error")
+	}
+	if zones.String() != "America/New_York,Europe/London" {
+		t.Errorf("Expected both zones, got %s", zones.String())
+	}
+	epochResults, _, err := epochconv.EpochCollection{epochconv.EpochUnix}.GuessesForStringsInZones(
+		[]string{"1720000000"}, 0, zones)
+	if err != nil {
+		t.Fatalf("Could not guess in zones: %s", err)
+	}
+	out := epochResultsAsString(epochResults[0], false)
+	for _, want := range []string{" UTC - 2024-07-03T09:46:40Z", " America/New_York - 2024-07-03T05:46:40-04:00",
+		" Europe/London - 2024-07-03T10:46:40+01:00"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output, got\n%s", want, out)
+		}
+	}
+}
+
+var referenceTimeTests = []struct {
+	in   string
+	want time.Time
+}{
+	{"2019-03-04", time.Date(2019, 3, 4, 0, 0, 0, 0, time.UTC)},
+	{"2019-03-04T05:06:07", time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)},
+	{"2019-03-04 05:06:07", time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)},
+	{"2019-03-04T05:06:07-05:00", time.Date(2019, 3, 4, 10, 6, 7, 0, time.UTC)},
+}
+
+// Tests whether -at reads dates with and without a time and offset, and is used as the clock.
+func TestReferenceTimeFlag(t *testing.T) {
+	for _, tt := range referenceTimeTests {
+		var at referenceTime
+		if err := at.Set(tt.in); err != nil {
+			t.Fatalf("Could not set -at %s: %s", tt.in, err)
+		}
+		o := options{at: at}
+		if now := o.clock().Now(); !now.Equal(tt.want) {
+			t.Errorf("-at %s should be %s, got %s", tt.in, tt.want, now)
+		}
+	}
+	var at referenceTime
+	if err := at.Set("2019-03"); err == nil {
+		t.Error("A month without a day should be an error")
+	}
+	if (&options{}).clock() != epochconv.SystemClock {
+		t.Error("Without -at the clock should be the system clock")
+	}
+}
+
+// Tests whether -rank finds rankers by name, with a date for the hint ranker from the flag or from -near.
+func TestRankerFlag(t *testing.T) {
+	o := options{}
+	if err := o.rank.Set("hint"); err != nil {
+		t.Fatalf("Could not set -rank hint: %s", err)
+	}
+	if err := o.near.Set("March 2014"); err != nil {
+		t.Fatalf("Could not set -near: %s", err)
+	}
+	if o.ranker() == nil {
+		t.Error("-rank hint should take its date from -near")
+	}
+	for _, value := range []string{"nearest", "past", "prior", "hint=2014-03-01"} {
+		var r rankerFlag
+		if err := r.Set(value); err != nil || r.ranker == nil {
+			t.Errorf("Could not set -rank %s: %v", value, err)
+		}
+	}
+	for _, value := range []string{"hint=March", "bogus"} {
+		var r rankerFlag
+		if err := r.Set(value); err == nil {
+			t.Errorf("-rank %s should be an error", value)
+		}
+	}
+}
+
+var formatDifferenceTests = []struct {
+	seconds int64
+	want    string
+}{
+	{0, "the same date"},
+	{90, "1m30s later"},
+	{-5 * 24 * 60 * 60, "5.0 days earlier"},
+	{1462 * 24 * 60 * 60, "4.0 years later"},
+}
+
+// Tests whether the distance to a tied date is described in the largest unit that fits.
+func TestFormatDifference(t *testing.T) {
+	for _, tt := range formatDifferenceTests {
+		if got := formatDifference(tt.seconds); got != tt.want {
+			t.Errorf("%d seconds should be %q, got %q", tt.seconds, tt.want, got)
+		}
+	}
+}
+
+var negativeArgsTests = []struct {
+	in, want []string
+}{
+	{[]string{"-all", "-86400", "5"}, []string{"-all", "--", "-86400", "5"}},
+	{[]string{"-min-confidence", "-0.5", "-3600"}, []string{"-min-confidence", "-0.5", "--", "-3600"}},
+	{[]string{"-min-confidence=0.5", "-0x10"}, []string{"-min-confidence=0.5", "--", "-0x10"}},
+	{[]string{"5", "-86400"}, []string{"5", "-86400"}},
+	{[]string{"--", "-86400"}, []string{"--", "-86400"}},
+	{[]string{"-nonsense", "-86400"}, []string{"-nonsense", "-86400"}},
+}
+
+// Tests whether negative numbers given as arguments are read as data rather than flags, and keep their sign.
+func TestNegativeNumbersAsArgs(t *testing.T) {
+	for _, tt := range negativeArgsTests {
+		if got := negativeNumbersAsArgs(tt.in); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("Expected %v to be %v, got %v", tt.in, tt.want, got)
+		}
+	}
+	strs := make([]epochconv.ExtractedNumber, 0)
+	epochStringsFromCommandLine(&strs, []string{"-86400"})
+	if len(strs) != 1 || strs[0].Number != "-86400" {
+		t.Errorf("Expected the sign to be kept on -86400, got %v", strs)
+	}
+}
+
+var strictTests = []struct {
+	in        string
+	ambiguous bool
+}{
+	// Microsoft COM and Excel agree on the date from serial 61 on
+	{"45000", false},
+	{"1600000000", false},
+}
+
+// Tests whether -strict only fails on numbers whose likely dates differ, not on epochs that agree on the date.
+func TestStrictAmbiguity(t *testing.T) {
+	o := options{strict: true, ambiguityMargin: epochconv.DefaultAmbiguityMargin}
+	if err := o.at.Set("2026-10-16"); err != nil {
+		t.Fatalf("Could not set -at: %s", err)
+	}
+	guesser := epochconv.NewGuesser(append(o.guesserOptions(), epochconv.WithMergedDuplicates())...)
+	for _, tt := range strictTests {
+		epochResults, _, _, err := guesser.GuessStrings([]string{tt.in})
+		if err != nil {
+			t.Fatalf("Could not guess %s: %s", tt.in, err)
+		}
+		if got := len(ambiguousInputs(epochResults)) > 0; got != tt.ambiguous {
+			t.Errorf("Expected %s to be ambiguous %t with -strict, got %t: %v", tt.in, tt.ambiguous, got,
+				epochResults[0].TiedWith)
+		}
+	}
+}
+
+var nearTests = []struct {
+	rank string
+	near string
+}{
+	{"", "2001"},
+	{"hint", "2001"},
+	{"nearest", "2001"},
+}
+
+// Tests whether -near a year finds the date in that year that may be a timestamp, rather than one a second after an
+// epoch started on the first of January, which is hidden as not a timestamp.
+func TestNearYear(t *testing.T) {
+	for _, tt := range nearTests {
+		o := options{ambiguityMargin: epochconv.DefaultAmbiguityMargin}
+		if err := o.at.Set("2026-10-16"); err != nil {
+			t.Fatalf("Could not set -at: %s", err)
+		}
+		if err := o.near.Set(tt.near); err != nil {
+			t.Fatalf("Could not set -near: %s", err)
+		}
+		if tt.rank != "" {
+			if err := o.rank.Set(tt.rank); err != nil {
+				t.Fatalf("Could not set -rank: %s", err)
+			}
+		}
+		epochResults, _, _, err := epochconv.NewGuesser(o.guesserOptions()...).GuessStrings([]string{"1000000000"})
+		if err != nil {
+			t.Fatalf("Could not guess: %s", err)
+		}
+		kept, _ := withoutNonTimestamps(epochResults)
+		if len(kept) != 1 {
+			t.Fatalf("Expected 1000000000 to be kept with -rank %q -near %s, got %s", tt.rank, tt.near,
+				epochResults[0].VerdictReason)
+		}
+		want := time.Date(2001, 9, 9, 1, 46, 40, 0, time.UTC)
+		if got := kept[0].AllResults[0]; got.EpochType.EpochName != "Unix" || !got.DateInEpochUTC.Equal(want) {
+			t.Errorf("Expected Unix %s with -rank %q -near %s, got %s %s", want, tt.rank, tt.near,
+				got.EpochType.Label(), got.DateInEpochUTC)
+		}
+	}
+}
@@ -7,33 +7,151 @@
 // Makes a best guess as to the type of epoch being used based on its relation to the current time.
 
 // The epochconv package is currently more flexible than the command line interface, allowing
-// time ranges, for instance.
+// custom epochs and rankers, for instance.
 
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"flag"
 	"github.com/deathbots/epochtool"
 	"github.com/fatih/color"
 	"fmt"
 	"os"
-	"io"
 	"strings"
+	"time"
 )
 
-//todo: flag to decide whether to print the time in UTC or in local timezone
 // Use Semver always
 var Version = "1.0.0-alpha.1"
 
 type options struct {
 	// The epoch date which is either given as a flag, or taken from clipboard
 	printVersionFlag   bool
-	epochsIn           []string
+	epochsIn           []epochconv.ExtractedNumber
 	useStdIn           bool
 	useClipboard       bool
 	colorOut           bool
 	emitJson           bool
 	showAllConversions bool
+	bareHex            bool
+	bareOctal          bool
+	byteSwaps          bool
+	zones              zoneList
+	at                 referenceTime
+	minConfidence      float64
+	after              referenceTime
+	before             referenceTime
+	rank               rankerFlag
+	near               approximateDate
+	ambiguityMargin    float64
+	strict             bool
+	batch              bool
+	showNonTimestamps  bool
+}
+
+// zoneList is the time zones given with -tz, which may be repeated to show several zones side by side.
+type zoneList []*time.Location
+
+// String satisfies flag.Value, printing the zone names comma separated.
+func (z *zoneList) String() string {
+	names := make([]string, len(*z))
+	for i, zone := range *z {
+		names[i] = zone.String()
+	}
+	return strings.Join(names, ",")
+}
+
+// Set satisfies flag.Value, loading the IANA zone by name. Local and UTC are also accepted.
+func (z *zoneList) Set(name string) error {
+	zone, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	*z = append(*z, zone)
+	return nil
+}
+
+// referenceTime is the instant given with -at, which numbers are ranked against instead of the current time.
+type referenceTime struct {
+	time.Time
+}
+
+// Layouts accepted by -at. Dates and times without an offset are read as UTC.
+var referenceTimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// String satisfies flag.Value, printing the instant in RFC 3339, or nothing when it is not set.
+func (r *referenceTime) String() string {
+	if r.IsZero() {
+		return ""
+	}
+	return r.Format(time.RFC3339Nano)
+}
+
+// Set satisfies flag.Value, reading the instant in any of the referenceTimeLayouts.
+func (r *referenceTime) Set(value string) error {
+	for _, layout := range referenceTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			r.Time = t
+			return nil
+		}
+	}
+	return fmt.Errorf("Date %q is not like 2006-01-02, 2006-01-02T15:04:05 or 2006-01-02T15:04:05Z07:00", value)
+}
+
+// rankerFlag is the Ranker named with -rank, one of epochconv.RankerNames. The hint ranker takes its date after an
+// equals sign, as in hint=2014-03-01, or from -near.
+type rankerFlag struct {
+	name   string
+	ranker epochconv.Ranker
+}
+
+// String satisfies flag.Value, printing the name given.
+func (r *rankerFlag) String() string {
+	return r.name
+}
+
+// Set satisfies flag.Value, finding the Ranker by name.
+func (r *rankerFlag) Set(value string) error {
+	name, date, _ := strings.Cut(value, "=")
+	var hint referenceTime
+	if date != "" {
+		if err := hint.Set(date); err != nil {
+			return err
+		}
+	} else if name == epochconv.RankerHint {
+		// the hint comes from -near, which may not have been read yet.
+		r.name, r.ranker = value, nil
+		return nil
+	}
+	ranker, err := epochconv.RankerByName(name, hint.Time)
+	if err != nil {
+		return err
+	}
+	r.name, r.ranker = value, ranker
+	return nil
+}
+
+// approximateDate is the rough date given with -near, see epochconv.ParseApproximatePeriod. The Time is the middle of
+// the period it names.
+type approximateDate struct {
+	time.Time
+	start, end time.Time
+	text       string
+}
+
+// String satisfies flag.Value, printing the date as given.
+func (a *approximateDate) String() string {
+	return a.text
+}
+
+// Set satisfies flag.Value, reading a rough date like March 2014.
+func (a *approximateDate) Set(value string) (err error) {
+	a.start, a.end, err = epochconv.ParseApproximatePeriod(value)
+	a.Time = a.start.Add(a.end.Sub(a.start) / 2)
+	a.text = value
+	return err
 }
 
 // Some globals
@@ -54,6 +172,7 @@ const (
 	exitNoNumbersParseableError
 	exitStdinError
 	exitJSONMarshallingError
+	exitAmbiguousError
 )
 
 const (
@@ -71,10 +190,39 @@ func init() {
 	flag.BoolVar(&opts.emitJson, "json", false, "Print output as data structure in JSON")
 	flag.BoolVar(&opts.showAllConversions, "all", false, "Show all matches for each parsed epoch, " +
 		"instead of the default case which is to show only the closest match.")
+	flag.BoolVar(&opts.bareHex, "hex", false, "Read numbers as hex, even without a 0x prefix. Otherwise 0x, 0o " +
+		"and 0b prefixes are detected.")
+	flag.BoolVar(&opts.bareOctal, "octal", false, "Read numbers as octal, even without a 0o prefix, as in tar " +
+		"headers. Otherwise a leading zero does not make a number octal.")
+	flag.BoolVar(&opts.byteSwaps, "swap", false, "Also try each number byte-swapped at 32 and 64 bits, as when " +
+		"copied from a memory dump in the wrong byte order.")
+	flag.Var(&opts.zones, "tz", "Show dates in an IANA time zone, such as America/New_York, with the offset in force " +
+		"on each date. Repeat to show several zones. Dates are shown in Local and UTC otherwise.")
+	flag.Var(&opts.at, "at", "Guess relative to this date instead of now, such as the date of an incident. Like " +
+		"2006-01-02, 2006-01-02T15:04:05 in UTC, or 2006-01-02T15:04:05-07:00.")
+	flag.Float64Var(&opts.minConfidence, "min-confidence", 0, "Leave out matches less confident than this, from " +
+		"0 to 1. Numbers with no match this confident are listed as having no plausible match.")
+	flag.Var(&opts.rank, "rank", "How to rank matches: " + strings.Join(epochconv.RankerNames, ", ") + ". Nearest " +
+		"is closest to now, past the most recent that is not in the future, prior weighs closeness by how common " +
+		"each epoch and unit are, and hint=2006-01-02 is closest to a date, or to -near. Defaults to prior.")
+	flag.Var(&opts.near, "near", "Prefer matches near a rough date the numbers are thought to be from, like " +
+		"'March 2014', 2014-03, 2014 or a full date and time, instead of near now. Every date in the month or " +
+		"year is as near.")
+	flag.Float64Var(&opts.ambiguityMargin, "ambiguity-margin", epochconv.DefaultAmbiguityMargin, "Warn that a " +
+		"number is ambiguous when another match is within this fraction of the most likely match's confidence.")
+	flag.Var(&opts.after, "after", "Only match dates on or after this date, such as the start of an incident. " +
+		"Numbers with no match in the window are listed as having no plausible match.")
+	flag.Var(&opts.before, "before", "Only match dates on or before this date. Dates without a time are midnight, " +
+		"so give the day after to include a whole day.")
+	flag.BoolVar(&opts.batch, "batch", false, "Treat all numbers as coming from one system, such as a column of " +
+		"timestamps, and find the one epoch that explains them best. Numbers that do not fit are marked as outliers.")
+	flag.BoolVar(&opts.showNonTimestamps, "show-non-timestamps", false, "Also show numbers which are not likely " +
+		"to be timestamps at all, such as 42, whose dates are far from any plausible date.")
+	flag.BoolVar(&opts.strict, "strict", false, "Exit with an error if any number is ambiguous, so scripts do not " +
+		"act on a guess that could have gone either way.")
 }
 
 func main() {
-	// todo: accept hex values as 0xXXXXXX and convert
 	// todo: try to parse out using regex any part of the clipboard string.
 	err := parseArgs()
 	if err != nil {
@@ -99,19 +247,47 @@ func main() {
 	if len(opts.epochsIn) == 0 {
 		fatalPrint(exitNoEpochStringsError, "No data from command line, clipboard, or stdin", nil)
 	}
-	deDuplicateStringSlice(&opts.epochsIn)
-	epochResults, badStrings, err := epochconv.GuessesForStrings(opts.epochsIn)
-	if err != nil {
-		stdErr("Could not parse the following input strings")
-		for _, badString := range badStrings {
-			stdErr(fmt.Sprintf("%s\n", badString))
+	if opts.batch {
+		printBatch(epochconv.NewGuesser(opts.guesserOptions()...))
+		return
+	}
+	// a number given many times is guessed once, and counted.
+	guesser := epochconv.NewGuesser(append(opts.guesserOptions(), epochconv.WithMergedDuplicates())...)
+	epochResults, _, noMatch, err := guesser.GuessExtracted(opts.epochsIn)
+	failures := parseFailures(err)
+	switch {
+	case len(failures) > 0 && opts.useClipboard:
+		stdErr("Some strings could not be parsed, but they will remain hidden in clipboard mode.")
+	case len(failures) > 0:
+		stdErr("Could not parse the following input strings:")
+		for _, failure := range failures {
+			stdErr(failureAsString(failure))
+		}
+	}
+	if len(noMatch) > 0 {
+		stdErr("No plausible match for the following numbers:")
+		for _, number := range noMatch {
+			stdErr(number)
 		}
 	}
 	if len(epochResults) == 0 {
 		fatalPrint(exitNoNumbersParseableError, "Found no numbers in input, cannot produce results\n", nil)
 	}
+	if !opts.showNonTimestamps {
+		var hidden []epochconv.EpochResults
+		epochResults, hidden = withoutNonTimestamps(epochResults)
+		if len(hidden) > 0 {
+			stdErr("Hiding the following numbers, which are not timestamps (use -show-non-timestamps to show them):")
+			for _, er := range hidden {
+				stdErr(fmt.Sprintf("%s - %s", er.InputText, er.VerdictReason))
+			}
+		}
+		if len(epochResults) == 0 {
+			fatalPrint(exitNoNumbersParseableError, "None of the numbers are likely to be timestamps", nil)
+		}
+	}
 	if opts.emitJson {
-		era := EpochResultsArray{EpochResultsArray: epochResults}
+		era := EpochResultsArray{EpochResultsArray: epochResults, ParseFailures: failures}
 		outJson, err := era.ToPrintableJson()
 		if err != nil {
 			fatalPrint(exitJSONMarshallingError, "Could not convert epoch results to JSON", err)
@@ -122,17 +298,88 @@ func main() {
 			// color output - Windows requires color.Output as the FPrint arg.
 			fmt.Fprintf(color.Output, "%s\n", epochResultsAsString(er, opts.showAllConversions))
 		}
+	}
+	if ambiguous := ambiguousInputs(epochResults); opts.strict && len(ambiguous) > 0 {
+		fatalPrint(exitAmbiguousError, fmt.Sprintf("%s is ambiguous, and -strict was given", ambiguous[0]), nil)
+	}
+}
 
+// printBatch guesses one epoch for all of the input numbers, and prints it with each number's date.
+func printBatch(guesser *epochconv.Guesser) {
+	batch, _, err := guesser.GuessBatchExtracted(opts.epochsIn)
+	failures := parseFailures(err)
+	if len(failures) > 0 {
+		stdErr("Could not parse the following input strings:")
+		for _, failure := range failures {
+			stdErr(failureAsString(failure))
+		}
+	} else if err != nil {
+		fatalPrint(exitNoNumbersParseableError, "Could not guess an epoch for the batch", err)
+	}
+	if opts.emitJson {
+		out := struct {
+			epochconv.BatchResult
+			ParseFailures []epochconv.ParseFailure `json:"parse_failures,omitempty"`
+		}{batch, failures}
+		outJson, err := json.MarshalIndent(&out, "", "  ")
 		if err != nil {
-			if opts.useClipboard {
-				stdErr("Some strings could not be parsed, but they will remain hidden in clipboard mode.")
-			}
-			stdErr("Could not parse the following input strings:")
-			for _, badString := range badStrings {
-				stdErr(fmt.Sprintf("%s", badString))
-			}
+			fatalPrint(exitJSONMarshallingError, "Could not convert batch result to JSON", err)
 		}
+		fmt.Println(string(outJson))
+		return
+	}
+	fmt.Fprintf(color.Output, "%s", batchResultAsString(batch))
+}
+
+// inputBase is the base numbers are read in, 0 to detect it from each number.
+func (o *options) inputBase() int {
+	if o.bareHex {
+		return 16
+	}
+	if o.bareOctal {
+		return 8
+	}
+	return 0
+}
+
+// collection is the epochs to guess from, with byte-swapped copies if asked for.
+func (o *options) collection() epochconv.EpochCollection {
+	if o.byteSwaps {
+		return epochconv.AllEpochs.WithByteSwaps()
+	}
+	return epochconv.AllEpochs
+}
+
+// guesserOptions configures the Guesser from the flags.
+func (o *options) guesserOptions() []epochconv.GuesserOption {
+	return []epochconv.GuesserOption{
+		epochconv.WithCollection(o.collection()),
+		epochconv.WithBase(o.inputBase()),
+		epochconv.WithZones(o.zones...),
+		epochconv.WithClock(o.clock()),
+		epochconv.WithMinConfidence(o.minConfidence),
+		epochconv.WithRanker(o.ranker()),
+		epochconv.WithNearPeriod(o.near.start, o.near.end),
+		epochconv.WithAmbiguityMargin(o.ambiguityMargin),
+		epochconv.WithWindow(o.after.Time, o.before.Time),
+	}
+}
+
+// ranker is the Ranker named with -rank. A hint ranker given without a date ranks by distance from the -near period,
+// which the guesser ranks against in place of now. It is nil, for the default, when -rank was not given.
+func (o *options) ranker() epochconv.Ranker {
+	if o.rank.ranker == nil && o.rank.name == epochconv.RankerHint {
+		return epochconv.NearestToNow
 	}
+	return o.rank.ranker
+}
+
+// clock is the clock numbers are ranked against, fixed at the -at date if one was given.
+func (o *options) clock() epochconv.Clock {
+	if o.at.IsZero() {
+		return epochconv.SystemClock
+	}
+	return epochconv.FixedClock(o.at.Time)
 }
 
 func parseArgs() (err error) {
@@ -143,6 +390,8 @@ func parseArgs() (err error) {
 		fmt.Printf("%s\nAccepts data to parse on command line, to stdin, or from the clipboard.\n", progFriendlyName)
 		fmt.Println("Command line parsing:")
 		fmt.Printf("\tUsage: %s -flags data1, data2 data3 \n", progFriendlyName)
+		fmt.Printf("\tNegative numbers are data, as in %s -all -86400, and flags after them are too.\n",
+			progFriendlyName)
 		fmt.Println("Stdin parsing:")
 		fmt.Printf("\tUsage: %s - < *.txt\n", progFriendlyName)
 		fmt.Println("Clipboard parsing:")
@@ -157,7 +406,8 @@ func parseArgs() (err error) {
 		}
 	}
 	opts.emitJson = false
-	flag.Parse()
+	// flag.Parse exits on a bad flag, as flag.CommandLine is set to, so the error needs no check.
+	flag.CommandLine.Parse(negativeNumbersAsArgs(os.Args[1:]))
 	if !opts.colorOut {
 		color.NoColor = true // disables colorized output
 	}
@@ -172,6 +422,15 @@ func parseArgs() (err error) {
 		os.Exit(exitNoError)
 	}
 	flag.VisitAll(defaultsChecker)
+	if !opts.after.IsZero() && !opts.before.IsZero() && opts.after.After(opts.before.Time) {
+		err = fmt.Errorf("-after %s is later than -before %s", &opts.after, &opts.before)
+	}
+	if opts.bareHex && opts.bareOctal {
+		err = fmt.Errorf("-hex and -octal cannot both be given")
+	}
+	if opts.rank.name == epochconv.RankerHint && opts.near.IsZero() {
+		err = fmt.Errorf("-rank hint needs a date, as in -rank hint=2014-03-01 or with -near")
+	}
 	if err != nil {
 		usage()
 		fmt.Printf("%s\n", err)
@@ -186,45 +445,67 @@ func parseArgs() (err error) {
 	return err
 }
 
-// epochStringsFromCommandLine collects strings from the os.args, before any start with -,
-// and adds to the collected strings list - passed by reference.
-func epochStringsFromCommandLine(sliceToFill *[]string, args []string) {
-	for _, arg := range epochconv.NumbersInStrings(args) {
-		*sliceToFill = append(*sliceToFill, arg)
+// negativeNumbersAsArgs puts a -- before the first argument that is a negative number, such as -86400, so the flag
+// package reads it and everything after it as data rather than as an undefined flag. A number given as the value of a
+// flag, as in -min-confidence -0.5, is left alone.
+func negativeNumbersAsArgs(args []string) []string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || arg == "-" || !strings.HasPrefix(arg, "-") {
+			// flags end here, so any negative numbers after this are already data.
+			return args
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := flag.CommandLine.Lookup(name)
+		if f == nil {
+			if _, _, err := epochconv.ParseEpochNumberInBase(arg, 0); err == nil {
+				return append(append(args[:i:i], "--"), args[i:]...)
+			}
+			return args
+		}
+		if boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool }); !hasValue && !(ok && boolFlag.IsBoolFlag()) {
+			i++ // skip over the flag's value
+		}
 	}
-	deDuplicateStringSlice(sliceToFill)
+	return args
 }
 
-// epochStringsFromStdin takes a slice of strings and adds items from stdin using fmt.Scan
-// which adds space-separated or newline separated values as successive items.
-func epochStringsFromStdin(sliceToFill *[]string) (err error) {
-	var s string
-	for {
-		_, err = fmt.Scan(&s)
-		if err != nil {
-			if err != io.EOF {
-				return err
-			}
-			// before we break, clear err which would simply be io.EOF
-			err = nil
-			break
-		}
-		*sliceToFill = append(*sliceToFill, s)
+// epochStringsFromCommandLine collects numbers from the os.args, before any start with -,
+// and adds to the collected numbers list - passed by reference.
+func epochStringsFromCommandLine(sliceToFill *[]epochconv.ExtractedNumber, args []string) {
+	*sliceToFill = append(*sliceToFill, epochconv.ExtractNumbers(args, opts.inputBase())...)
+}
+
+// epochStringsFromStdin reads stdin line by line until it ends, and adds the numbers found in each line, with the line
+// and column they were found at, to the collected numbers list - passed by reference.
+func epochStringsFromStdin(sliceToFill *[]epochconv.ExtractedNumber) (err error) {
+	var lines []string
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err = scanner.Err(); err != nil {
+		return err
 	}
-	epochconv.NumbersInStrings(*sliceToFill)
-	deDuplicateStringSlice(sliceToFill)
+	*sliceToFill = append(*sliceToFill, numbersFromSource("stdin", lines)...)
 	return err
 }
 
-func epochStringsFromClipboard(sliceToFill *[]string) (err error) {
+func epochStringsFromClipboard(sliceToFill *[]epochconv.ExtractedNumber) (err error) {
 	s, err := getClipboardString()
 	if err != nil {
 		return err
 	}
-	splitters := []string{"\n", "\r\n", "\t", ","}
-	for _, splitter := range splitters {
-		*sliceToFill = append(*sliceToFill, epochconv.NumbersInStrings(strings.Split(s, splitter))...)
-	}
-	deDuplicateStringSlice(sliceToFill)
+	// numbers are found between any separators, and digit groups like 1,600,000,000 are kept whole.
+	*sliceToFill = append(*sliceToFill, numbersFromSource("clipboard", strings.Split(s, "\n"))...)
 	return err
+}
+
+// numbersFromSource extracts the numbers in the lines, naming the source they came from in their positions.
+func numbersFromSource(source string, lines []string) []epochconv.ExtractedNumber {
+	numbers := epochconv.ExtractNumbers(lines, opts.inputBase())
+	for i := range numbers {
+		numbers[i].Position.Source = source
+	}
+	return numbers
 }
\ No newline at end of file
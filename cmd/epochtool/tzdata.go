@@ -0,0 +1,5 @@
+package main
+
+// The zone database is embedded, so -tz gives the same offsets on systems without one installed, such as Windows and
+// minimal containers. The system database is still used first when there is one.
+import _ "time/tzdata"
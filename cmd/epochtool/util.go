@@ -8,16 +8,19 @@ import (
 	"github.com/deathbots/epochtool"
 	"github.com/fatih/color"
 	"os"
+	"strings"
 	"time"
 )
 
 // This type is used only for JSON marshalling.
 type EpochResultsArray struct {
 	EpochResultsArray []epochconv.EpochResults `json:"epoch_results_array"`
+	ParseFailures     []epochconv.ParseFailure `json:"parse_failures,omitempty"` // Inputs not converted, and why
 }
 
 var (
 	colorMostLikely = color.New(color.FgHiGreen).SprintFunc()
+	colorWarning    = color.New(color.FgHiRed).SprintFunc()
 )
 
 // fatalPrint is a convenience function that will quit the program with the specified Exit Code, print some friendly
@@ -44,27 +47,24 @@ func getClipboardString() (out string, err error) {
 	return clipboard.ReadAll()
 }
 
-func deDuplicateStringSlice(sliceToDeDupe *[]string) {
-	found := make(map[string]bool)
-	j := 0
-	for i, x := range *sliceToDeDupe {
-		if !found[x] {
-			found[x] = true
-			(*sliceToDeDupe)[j] = (*sliceToDeDupe)[i]
-			j++
-		}
-	}
-	*sliceToDeDupe = (*sliceToDeDupe)[:j]
-}
-
 func epochResultsAsString(ers epochconv.EpochResults, showAll bool) string {
 	var out string
 	// for non-string types that are printable via %s, you must turn them to strings first
 	// in order to apply a color.
 	colorMe := fmt.Sprintf("%s", ers.MostLikelyType)
-	out = out + fmt.Sprintf("For Input Number: %d\n"+
+	if i := mostLikelyIndex(ers); i >= 0 {
+		er := ers.AllResults[i]
+		colorMe = datesAsString(er.DateInEpochLocal, er.DateInEpochUTC, er.DatesInZones, er.Precision) + colorMe
+	}
+	out = out + fmt.Sprintf("For Input Number: %s%s%s%s%s\n"+
 		"---------Most Likely Result----\n"+
-		"%s", ers.InputNumber, colorMostLikely(colorMe))
+		"%s (confidence %s)\n"+
+		"%s"+
+		"%s"+
+		"%s", ers.InputText, normalizedAsString(ers.NormalizedText), baseName(ers.InputBase),
+		occurrencesAsString(ers.Occurrences), positionAsString(ers.Position), ers.MostLikelyType.Label(),
+		formatConfidence(ers.Confidence), colorWarning(verdictAsString(ers)), colorWarning(ambiguityAsString(ers)),
+		colorMostLikely(colorMe))
 	if !showAll {
 		return out
 	}
@@ -88,29 +88,223 @@ func epochResultsAsString(ers epochconv.EpochResults, showAll bool) string {
 		if er.EpochType.Prevalence < 3 {
 			c = color.New(color.Faint).SprintfFunc()
 		}
-		m := fmt.Sprintf("%d in '%s' Epoch:\n"+
-			" Local - %s\n"+
-			" UTC - %s\n"+
-			"%s\n", ers.InputNumber, er.EpochType.EpochName, er.DateInEpochLocal.Format(time.RFC3339),
-			er.DateInEpochUTC.Format(time.RFC3339), er.EpochType)
-		out = out + fmt.Sprintf("%s", c(m))
+		m := fmt.Sprintf("%s as %s (confidence %s):\n"+
+			"%s"+
+			"%s\n", er.InputValue, er.EpochType.Label(), formatConfidence(er.Confidence),
+			datesAsString(er.DateInEpochLocal, er.DateInEpochUTC, er.DatesInZones, er.Precision), er.EpochType)
+		if er.EpochType.Encoding == epochconv.EncodingDateTimeBinary {
+			m = m + fmt.Sprintf(" DateTimeKind - %s\n", er.DateTimeKind)
+		}
+		if er.DecodedValue.Cmp(er.InputValue) != 0 {
+			m = m + fmt.Sprintf(" Counted as - %s\n", er.DecodedValue)
+		}
+		out = out + c("%s", m)
 	}
 
 	return out
 }
 
+// batchResultAsString prints the epoch found for a batch, then each number with its date in that epoch, marking the
+// outliers.
+func batchResultAsString(batch epochconv.BatchResult) string {
+	colorMe := fmt.Sprintf("%s", batch.EpochType)
+	out := fmt.Sprintf("For Batch of %d Numbers:\n"+
+		"---------Most Likely Result----\n"+
+		"%s (confidence %s)\n"+
+		"%s", len(batch.Values), batch.EpochType.Label(), formatConfidence(batch.Confidence), colorMostLikely(colorMe))
+	if batch.UnitEvidence.Timestamps {
+		out = out + fmt.Sprintf("Gaps between numbers: %s, %d of %d going up\n", batch.UnitEvidence.Reason,
+			batch.UnitEvidence.Increasing, batch.UnitEvidence.Deltas)
+	} else {
+		out = out + colorWarning(fmt.Sprintf("Gaps between numbers: %s\n", batch.UnitEvidence.Reason))
+	}
+	out = out + fmt.Sprint("---------Numbers---------------\n")
+	for _, value := range batch.Values {
+		date := "no date"
+		if value.Converted {
+			date = formatDate(value.DateInEpochUTC, value.Precision)
+		}
+		line := fmt.Sprintf("%s%s - %s", value.InputValue, positionAsString(value.Position), date)
+		if value.Outlier {
+			line = colorWarning(fmt.Sprintf("%s (outlier, %s)", line, value.OutlierReason))
+		}
+		out = out + line + "\n"
+	}
+	if len(batch.Outliers) > 0 {
+		out = out + colorWarning(fmt.Sprintf("%d of %d numbers do not fit\n", len(batch.Outliers), len(batch.Values)))
+	}
+	return out
+}
+
 func epochTopResultAsString(ers epochconv.EpochResults) string {
 	var out string
 	// for non-string types that are printable via %s, you must turn them to strings first
 	// in order to apply a color.
 	colorMe := fmt.Sprintf("%s", ers.MostLikelyType)
-	out = out + fmt.Sprintf("For Input Number: %d\n"+
+	out = out + fmt.Sprintf("For Input Number: %s\n"+
 		"---------Most Likely Result----\n"+
 		"%s"+
-		"---------Other Results---------\n", ers.InputNumber, colorMostLikely(colorMe))
+		"---------Other Results---------\n", ers.InputValue, colorMostLikely(colorMe))
+	return out
+}
+
+// mostLikelyIndex is the position in AllResults of the result for the MostLikelyType, or -1.
+func mostLikelyIndex(ers epochconv.EpochResults) int {
+	for i, er := range ers.AllResults {
+		if er.EpochType.Label() == ers.MostLikelyType.Label() {
+			return i
+		}
+	}
+	return -1
+}
+
+// datesAsString prints a converted date in UTC and in each zone given with -tz, or in Local and UTC when no zones
+// were given.
+func datesAsString(local, utc time.Time, zoned []epochconv.ZonedDate, precision time.Duration) string {
+	if len(zoned) == 0 {
+		return fmt.Sprintf(" Local - %s\n UTC - %s\n", formatDate(local, precision), formatDate(utc, precision))
+	}
+	out := fmt.Sprintf(" UTC - %s\n", formatDate(utc, precision))
+	for _, zd := range zoned {
+		out = out + fmt.Sprintf(" %s - %s\n", zd.Zone, formatDate(zd.Date, precision))
+	}
+	return out
+}
+
+// withoutNonTimestamps splits the results into those that may be timestamps, and those that are not timestamps.
+func withoutNonTimestamps(epochResults []epochconv.EpochResults) (kept, hidden []epochconv.EpochResults) {
+	for _, er := range epochResults {
+		if er.Verdict == epochconv.VerdictNotTimestamp {
+			hidden = append(hidden, er)
+		} else {
+			kept = append(kept, er)
+		}
+	}
+	return kept, hidden
+}
+
+// ambiguousInputs lists the numbers, as they were written, whose results are ambiguous. With -strict, there must be
+// none.
+func ambiguousInputs(epochResults []epochconv.EpochResults) (inputs []string) {
+	for _, er := range epochResults {
+		if er.Ambiguous {
+			inputs = append(inputs, er.InputText)
+		}
+	}
+	return inputs
+}
+
+// verdictAsString warns when a number is not likely to be a timestamp, and is empty when it is.
+func verdictAsString(ers epochconv.EpochResults) string {
+	if ers.Verdict == epochconv.VerdictTimestamp {
+		return ""
+	}
+	return fmt.Sprintf("Warning: %s, %s\n", ers.Verdict, ers.VerdictReason)
+}
+
+// ambiguityAsString warns that other results are nearly as likely as the most likely result, listing how far each
+// one's date is from it. It is empty when the result is not ambiguous.
+func ambiguityAsString(ers epochconv.EpochResults) string {
+	if !ers.Ambiguous {
+		return ""
+	}
+	out := "Warning: ambiguous, nearly as likely are:\n"
+	for _, tie := range ers.TiedWith {
+		out = out + fmt.Sprintf(" %s (confidence %s), %s\n", tie.EpochType.Label(), formatConfidence(tie.Confidence),
+			formatDifference(tie.SecondsFromMostLikely))
+	}
 	return out
 }
 
+// formatDifference describes how far a date is from the most likely date, in the largest unit that fits.
+func formatDifference(seconds int64) string {
+	direction := "later"
+	if seconds < 0 {
+		direction, seconds = "earlier", -seconds
+	}
+	switch {
+	case seconds == 0:
+		return "the same date"
+	case seconds >= 365*24*60*60:
+		return fmt.Sprintf("%.1f years %s", float64(seconds)/(365.2425*24*60*60), direction)
+	case seconds >= 24*60*60:
+		return fmt.Sprintf("%.1f days %s", float64(seconds)/(24*60*60), direction)
+	default:
+		return fmt.Sprintf("%s %s", time.Duration(seconds)*time.Second, direction)
+	}
+}
+
+// normalizedAsString shows the number with its digits read as ASCII, when it was written in another script's digits,
+// such as full-width digits pasted from a chat. It is empty otherwise.
+func normalizedAsString(normalized string) string {
+	if normalized == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (digits read as %s)", normalized)
+}
+
+// occurrencesAsString counts how many times a number was given, and is empty when it was given once.
+func occurrencesAsString(occurrences int) string {
+	if occurrences < 2 {
+		return ""
+	}
+	return fmt.Sprintf(", seen %d times", occurrences)
+}
+
+// parseFailures returns the inputs a guess could not convert, with the reason for each, from the error it returned.
+func parseFailures(err error) []epochconv.ParseFailure {
+	var parseErr *epochconv.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.Failures
+	}
+	return nil
+}
+
+// failureAsString prints an input which could not be converted with the reason, and where it was found.
+func failureAsString(failure epochconv.ParseFailure) string {
+	return fmt.Sprintf("%q - %s%s", failure.Input, failure.Reason, positionAsString(failure.Position))
+}
+
+// positionAsString names where a number was read from, such as stdin line 42 col 17. It is empty for numbers given
+// as arguments, which are where they were typed.
+func positionAsString(position epochconv.Position) string {
+	if position.Source == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s line %d col %d)", position.Source, position.Line, position.Column)
+}
+
+// formatConfidence prints a confidence from 0 to 1 as a percentage.
+func formatConfidence(confidence float64) string {
+	return fmt.Sprintf("%.1f%%", confidence*100)
+}
+
+// baseName describes the base an input number was read in, or nothing for base 10.
+func baseName(base int) string {
+	switch base {
+	case 16:
+		return " (read as hex)"
+	case 8:
+		return " (read as octal)"
+	case 2:
+		return " (read as binary)"
+	default:
+		return ""
+	}
+}
+
+// formatDate prints a date in RFC 3339, with as many digits of fractional seconds as the input number was precise to.
+func formatDate(date time.Time, precision time.Duration) string {
+	digits := 0
+	for step := precision; step < time.Second && digits < 9; step *= 10 {
+		digits++
+	}
+	if digits == 0 {
+		return date.Format(time.RFC3339)
+	}
+	return date.Format("2006-01-02T15:04:05." + strings.Repeat("0", digits) + "Z07:00")
+}
+
 // easy conversion of this type made for JSON marshalling to json
 func (era EpochResultsArray) ToPrintableJson() (string, error) {
 	jsonByteArray, err := json.MarshalIndent(&era, "", "  ")
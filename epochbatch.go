@@ -0,0 +1,354 @@
+package epochconv
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Holds the guessing of one epoch for a whole batch of numbers.
+
+// BatchResult is the one epoch, counted in one unit and encoding, that best explains a batch of numbers taken from
+// the same system, such as a column of timestamps. Every number is converted in that epoch, so the rows agree with
+// each other even where a single number on its own would be guessed as another epoch.
+type BatchResult struct {
+	EpochType    EpochType    `json:"epoch_type"`
+	Confidence   float64      `json:"confidence"`    // From 0 to 1, the mean confidence of EpochType over every number
+	Values       []BatchValue `json:"values"`        // One for each number, in the order given
+	Outliers     []int        `json:"outliers"`      // Positions in Values of the numbers that do not fit
+	UnitEvidence UnitEvidence `json:"unit_evidence"` // What the gaps between the numbers say about their unit
+}
+
+// BatchValue is one number of a batch, converted in the batch's epoch.
+type BatchValue struct {
+	InputValue       EpochNumber   `json:"input_value"`
+	Converted        bool          `json:"converted"` // False when the number has no date in the batch's epoch
+	DateInEpochLocal time.Time     `json:"converted_date_local"`
+	DateInEpochUTC   time.Time     `json:"converted_date_utc"`
+	DatesInZones     []ZonedDate   `json:"converted_dates_in_zones,omitempty"`
+	Precision        time.Duration `json:"precision_ns"`
+	Outlier          bool          `json:"outlier"`
+	OutlierReason    string        `json:"outlier_reason,omitempty"`
+	Position         Position      `json:"position"` // Where the number was found, empty for numbers not read from text
+}
+
+// outlierSpread is how many median absolute deviations from the median date a date may be before it is an outlier.
+// A date is never an outlier for being within a day of the median.
+const outlierSpread = 10
+
+// GuessBatch is a method on a Guesser. Rather than guessing each number on its own, it finds the one epoch that best
+// explains all of the numbers, by adding up each epoch's confidence for every number. When the gaps between the
+// numbers look like those between times, epochs counted in units the gaps are plausible in are preferred, see
+// InferUnitFromDeltas. Numbers that do not fit - with no date in the epoch, outside the window, or far from the dates
+// of the rest - are listed as Outliers. Strings which are not numbers are returned in badStrings, and when the batch
+// is still guessed, err is a *ParseError giving the reason each failed.
+func (g *Guesser) GuessBatch(stringsToConvert []string) (batch BatchResult, badStrings []string, err error) {
+	inputs, failures := stringSliceToEpochNumbers(stringsToConvert, g.config.base)
+	return g.config.guessBatchWithFailures(inputs, failures)
+}
+
+// GuessBatchExtracted is a method on a Guesser. It is GuessBatch for numbers found by ExtractNumbers, and each value
+// keeps the Position its number was found at.
+func (g *Guesser) GuessBatchExtracted(numbers []ExtractedNumber) (batch BatchResult, badStrings []string,
+	err error) {
+	inputs, failures := extractedToEpochNumbers(numbers, g.config.base)
+	return g.config.guessBatchWithFailures(inputs, failures)
+}
+
+// GuessBatchInt64s is a method on a Guesser. It is GuessBatch for numbers which have already been read.
+func (g *Guesser) GuessBatchInt64s(numbers []int64) (batch BatchResult, err error) {
+	inputs := make([]parsedInput, len(numbers))
+	for i, number := range numbers {
+		inputs[i] = parsedInput{value: NewEpochNumber(number), base: 10}
+	}
+	return g.config.guessBatch(inputs)
+}
+
+// guessBatchWithFailures is guessBatch, returning the failures as badStrings and a *ParseError when the batch is
+// guessed.
+func (c guessConfig) guessBatchWithFailures(inputs []parsedInput, failures []ParseFailure) (batch BatchResult,
+	badStrings []string, err error) {
+	parseErr := &ParseError{Failures: failures}
+	badStrings = parseErr.Inputs()
+	if batch, err = c.guessBatch(inputs); err == nil && len(failures) > 0 {
+		err = parseErr
+	}
+	return batch, badStrings, err
+}
+
+func (c guessConfig) guessBatch(inputs []parsedInput) (batch BatchResult, err error) {
+	if len(inputs) == 0 {
+		return batch, errors.New("No numbers to guess a batch from")
+	}
+	numbers := make([]EpochNumber, len(inputs))
+	for i, in := range inputs {
+		numbers[i] = in.value
+	}
+	batch.UnitEvidence = inferUnitFromDeltas(numbers, c.candidates().units()...)
+	best, total, ok := c.bestForBatch(inputs, batch.UnitEvidence)
+	if !ok {
+		return batch, errors.New("No epoch has a plausible date for any of the numbers")
+	}
+	batch.EpochType = best
+	batch.Confidence = total / float64(len(inputs))
+	batch.UnitEvidence.agreeWith(best.Unit)
+	for _, in := range inputs {
+		value := BatchValue{InputValue: in.value, Position: in.position}
+		er, ok := c.resultFor(in.value, best)
+		switch {
+		case !ok:
+			value.Outlier, value.OutlierReason = true, "no date in the epoch"
+		case !c.inWindow(er.DateInEpochUTC):
+			value.Outlier, value.OutlierReason = true, "outside the window"
+		}
+		if ok {
+			value.Converted = true
+			value.DateInEpochLocal, value.DateInEpochUTC = er.DateInEpochLocal, er.DateInEpochUTC
+			value.DatesInZones, value.Precision = er.DatesInZones, er.Precision
+		}
+		batch.Values = append(batch.Values, value)
+	}
+	batch.markDistantDates()
+	for i, value := range batch.Values {
+		if value.Outlier {
+			batch.Outliers = append(batch.Outliers, i)
+		}
+	}
+	return batch, nil
+}
+
+// bestForBatch adds up the confidence of each epoch over every number, guessed one at a time, and returns the epoch
+// with the highest total. When the numbers look like timestamps, epochs counted in units the gaps between them are
+// not plausible in are weighed down. Epochs with the same total go to the one which started first. The total
+// returned is not weighed.
+func (c guessConfig) bestForBatch(inputs []parsedInput, evidence UnitEvidence) (best EpochType, bestTotal float64,
+	ok bool) {
+	epochResults, _, _, _ := c.guessInputs(inputs, nil)
+	totals := make(map[string]float64)
+	var epochs EpochCollection
+	for _, ers := range epochResults {
+		for _, er := range ers.AllResults {
+			label := er.EpochType.Label()
+			if _, seen := totals[label]; !seen {
+				epochs = append(epochs, er.EpochType)
+			}
+			totals[label] += er.Confidence
+		}
+	}
+	sort.Stable(ByEpochDate(epochs))
+	bestWeighed := 0.0
+	for _, et := range epochs {
+		total := totals[et.Label()]
+		weighed := total * evidence.weight(et.Unit)
+		if !ok || weighed > bestWeighed {
+			best, bestTotal, bestWeighed, ok = et, total, weighed, true
+		}
+	}
+	return best, bestTotal, ok
+}
+
+// markDistantDates marks the converted values whose date is far from the median date of the batch as outliers, such
+// as a stray small number in a column of recent timestamps.
+func (b *BatchResult) markDistantDates() {
+	var seconds []int64
+	for _, value := range b.Values {
+		if !value.Outlier {
+			seconds = append(seconds, value.DateInEpochUTC.Unix())
+		}
+	}
+	if len(seconds) == 0 {
+		return
+	}
+	median := medianOf(seconds)
+	deviations := make([]int64, len(seconds))
+	for i, s := range seconds {
+		deviations[i] = absInt64(s - median)
+	}
+	limit := outlierSpread * medianOf(deviations)
+	if limit < secondsPerDay {
+		limit = secondsPerDay
+	}
+	for i, value := range b.Values {
+		if !value.Outlier && absInt64(value.DateInEpochUTC.Unix()-median) > limit {
+			b.Values[i].Outlier, b.Values[i].OutlierReason = true, "far from the dates of the other numbers"
+		}
+	}
+}
+
+// medianOf returns the median of the numbers, the lower of the middle two for an even count. The slice is sorted.
+func medianOf(numbers []int64) int64 {
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	return numbers[(len(numbers)-1)/2]
+}
+
+// absInt64 returns the size of n. The size of math.MinInt64 does not fit in an int64, so is math.MaxInt64.
+func absInt64(n int64) int64 {
+	switch {
+	case n == math.MinInt64:
+		return math.MaxInt64
+	case n < 0:
+		return -n
+	}
+	return n
+}
+
+// medianOfNumbers is medianOf for EpochNumbers. The slice is sorted.
+func medianOfNumbers(numbers []EpochNumber) EpochNumber {
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i].Cmp(numbers[j]) < 0 })
+	return numbers[(len(numbers)-1)/2]
+}
+
+// UnitEvidence is what the differences between consecutive numbers of a series, such as the times in a log, say about
+// the unit they are counted in. Log times are usually in order, with gaps from a fraction of a second to days, so a
+// gap of 1500000000 is plausible in milliseconds or finer units, but not in seconds. This is separate from how
+// plausible each number's date is on its own, the test used by OrderedEpochsByClosestMatch and the Rankers.
+type UnitEvidence struct {
+	Unit          EpochUnit   `json:"unit"`           // The unit with the most typical gap, unspecified when none fit
+	Units         []EpochUnit `json:"units"`          // Every unit the gaps are plausible in
+	Timestamps    bool        `json:"timestamps"`     // False for a counter, or when no unit fits the gaps
+	Deltas        int         `json:"deltas"`         // How many differences between consecutive numbers there are
+	Increasing    int         `json:"increasing"`     // How many of the differences are above zero
+	Decreasing    int         `json:"decreasing"`     // How many of the differences are below zero
+	MedianDelta   EpochNumber `json:"median_delta"`   // The median size of the differences which are not zero
+	SmallestDelta EpochNumber `json:"smallest_delta"` // The smallest size of a difference which is not zero
+	LargestDelta  EpochNumber `json:"largest_delta"`  // The largest size of a difference
+	Reason        string      `json:"reason"`
+}
+
+// implausibleGapWeight is how much less likely an epoch is for a batch when it is counted in a unit the gaps between
+// the numbers are not plausible in. It is not zero, so that a batch of sparse dates, such as one a year, is still
+// guessed in the unit its dates are plausible in.
+const implausibleGapWeight = 0.1
+
+// weight is a method on UnitEvidence. It is 1 for a unit the gaps are plausible in, or for any unit when the numbers do
+// not look like timestamps, and implausibleGapWeight otherwise.
+func (e UnitEvidence) weight(unit EpochUnit) float64 {
+	if !e.Timestamps {
+		return 1
+	}
+	for _, plausible := range e.Units {
+		if plausible == unit.effective() {
+			return 1
+		}
+	}
+	return implausibleGapWeight
+}
+
+// The range of gaps between log times that are plausible, in seconds, and the range of cadences most typical of logs
+// and samples, from a tenth of a second to a day.
+const (
+	smallestGap        = 1e-6
+	largestGap         = 31 * secondsPerDay
+	smallestTypicalGap = 0.1
+	largestTypicalGap  = secondsPerDay
+)
+
+// InferUnitFromDeltas looks at the differences between consecutive numbers, in the order given, to tell which of the
+// units the numbers are counted in, DefaultUnits when none are given. A unit fits when the median gap, in that unit,
+// is from a microsecond to a month. The units whose median gap is a typical cadence, from a tenth of a second to a
+// day, are the most likely, and of those the one whose gap is closest to a second; so a gap of 60 is a minute in
+// seconds rather than 60 milliseconds. A series that only ever goes up by one is a counter, not timestamps. The differences are exact, however far apart the
+// numbers are.
+func InferUnitFromDeltas(numbers []int64, units ...EpochUnit) (evidence UnitEvidence) {
+	values := make([]EpochNumber, len(numbers))
+	for i, number := range numbers {
+		values[i] = NewEpochNumber(number)
+	}
+	return inferUnitFromDeltas(values, units...)
+}
+
+// inferUnitFromDeltas is InferUnitFromDeltas for numbers of any size, which may have a decimal part, such as the
+// times from strace -ttt.
+func inferUnitFromDeltas(numbers []EpochNumber, units ...EpochUnit) (evidence UnitEvidence) {
+	if len(units) == 0 {
+		units = DefaultUnits
+	}
+	var sizes []EpochNumber
+	for i := 1; i < len(numbers); i++ {
+		delta := numbers[i].sub(numbers[i-1])
+		evidence.Deltas++
+		switch delta.Cmp(EpochNumber{}) {
+		case 1:
+			evidence.Increasing++
+		case -1:
+			evidence.Decreasing++
+		default:
+			continue
+		}
+		size := delta.abs()
+		sizes = append(sizes, size)
+		if len(sizes) == 1 || size.Cmp(evidence.SmallestDelta) < 0 {
+			evidence.SmallestDelta = size
+		}
+		if size.Cmp(evidence.LargestDelta) > 0 {
+			evidence.LargestDelta = size
+		}
+	}
+	switch {
+	case len(numbers) < 2:
+		evidence.Reason = "Fewer than two numbers, so there are no gaps to measure"
+		return evidence
+	case len(sizes) == 0:
+		evidence.Reason = "Every number is the same, so there are no gaps to measure"
+		return evidence
+	case evidence.Deltas >= 2 && evidence.Increasing == evidence.Deltas &&
+		evidence.LargestDelta.Cmp(NewEpochNumber(1)) == 0:
+		evidence.Reason = "Each number is one more than the last, as in a counter rather than times"
+		return evidence
+	}
+	evidence.MedianDelta = medianOfNumbers(sizes)
+	bestDistance := math.Inf(1)
+	for _, unit := range units {
+		gap := evidence.MedianDelta.float64() * float64(unit.nanoseconds()) / 1e9
+		if gap < smallestGap || gap > largestGap {
+			continue
+		}
+		evidence.Units = append(evidence.Units, unit.effective())
+		distance := math.Abs(math.Log10(gap))
+		if gap < smallestTypicalGap || gap > largestTypicalGap {
+			// any typical cadence is more likely than every atypical one.
+			distance += largestGap
+		}
+		if distance < bestDistance {
+			evidence.Unit, bestDistance = unit.effective(), distance
+		}
+	}
+	if len(evidence.Units) == 0 {
+		evidence.Reason = fmt.Sprintf("A median gap of %s is not plausible between times in any unit",
+			evidence.MedianDelta)
+		return evidence
+	}
+	evidence.Timestamps = true
+	evidence.Reason = evidence.plausibleReason()
+	return evidence
+}
+
+// plausibleReason is the Reason for gaps which are plausible in the Unit.
+func (e UnitEvidence) plausibleReason() string {
+	reason := fmt.Sprintf("A median gap of %s is plausible in %s", e.MedianDelta, e.Unit)
+	if e.Increasing > 0 && e.Decreasing > 0 {
+		reason += ", though the numbers are not in order"
+	}
+	return reason
+}
+
+// agreeWith is a method on UnitEvidence. It makes the Unit the one a batch was guessed in, when the gaps are plausible
+// in it, so the evidence does not name another unit than the batch's epoch. When they are not plausible in it, the
+// Unit is left unspecified.
+func (e *UnitEvidence) agreeWith(unit EpochUnit) {
+	if !e.Timestamps || e.Unit == unit.effective() {
+		return
+	}
+	for _, plausible := range e.Units {
+		if plausible == unit.effective() {
+			e.Unit = plausible
+			e.Reason = e.plausibleReason()
+			return
+		}
+	}
+	e.Reason = fmt.Sprintf("A median gap of %s is not plausible in %s, though the dates are", e.MedianDelta,
+		unit.effective())
+	e.Unit = UnitUnspecified
+}
@@ -0,0 +1,90 @@
+package epochconv
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Holds the clock that guesses are made relative to.
+
+// Clock gives the time that numbers are ranked against when guessing, which is normally the current time. Give a
+// FixedClock to guess relative to another instant, such as the date of an incident being investigated, or to make
+// guesses repeatable in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock used when none is given. It reads the system time on every call.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+// Now satisfies the Clock interface, returning time.Now.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock is a Clock which is always at the same instant.
+type FixedClock time.Time
+
+// Now satisfies the Clock interface, returning the fixed instant.
+func (c FixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// clockOrSystem returns the clock, or the SystemClock if it is nil.
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock
+	}
+	return clock
+}
+
+// Layouts for ParseApproximateDate, each with the length of the period it names.
+var approximateLayouts = []struct {
+	layout string
+	years  int
+	months int
+	days   int
+}{
+	{time.RFC3339Nano, 0, 0, 0},
+	{"2006-01-02T15:04:05", 0, 0, 0},
+	{"2006-01-02 15:04:05", 0, 0, 0},
+	{"2006-01-02", 0, 0, 1},
+	{"2 January 2006", 0, 0, 1},
+	{"January 2, 2006", 0, 0, 1},
+	{"2006-01", 0, 1, 0},
+	{"January 2006", 0, 1, 0},
+	{"Jan 2006", 0, 1, 0},
+	{"2006", 1, 0, 0},
+}
+
+// ParseApproximateDate reads a rough date, such as "March 2014", "2014-03", "2014" or "around 2014-03-15", and
+// returns the middle of the period it names, in UTC. A date and time, as in RFC 3339, is returned as it is. Use it
+// with WithNear to rank numbers by how close they are to when they are thought to have been written.
+func ParseApproximateDate(s string) (time.Time, error) {
+	start, end, err := ParseApproximatePeriod(s)
+	return start.Add(end.Sub(start) / 2), err
+}
+
+// ParseApproximatePeriod reads a rough date like ParseApproximateDate, and returns the start and end of the period it
+// names, in UTC: all of 2014 for "2014", or all of March for "March 2014". A date and time, as in RFC 3339, starts
+// and ends at that time. Use it with WithNearPeriod to rank every date in the period as near.
+func ParseApproximatePeriod(s string) (start, end time.Time, err error) {
+	value := strings.TrimSpace(s)
+	for _, word := range []string{"around ", "about ", "circa ", "~"} {
+		if strings.HasPrefix(strings.ToLower(value), word) {
+			value = strings.TrimSpace(value[len(word):])
+			break
+		}
+	}
+	for _, approximate := range approximateLayouts {
+		date, err := time.Parse(approximate.layout, value)
+		if err != nil {
+			continue
+		}
+		return date, date.AddDate(approximate.years, approximate.months, approximate.days), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("Date %q is not like March 2014, 2014-03, 2014 or 2014-03-15", s)
+}
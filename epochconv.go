@@ -10,166 +10,426 @@ package epochconv
 import (
 	"fmt"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
-	"regexp"
 )
 
-//todo: JS Epoch (unix in milliseconds) currently unhandled?
-// perhaps for each number, divide and see if it's close to unix?
-
 // epochResult is used in an EpochResultBundle
 type epochResult struct {
-	InputNumber      int64     `json:"input_number"`
-	EpochType        EpochType `json:"epoch_type"`
-	DateInEpochLocal time.Time `json:"converted_date_local"`
-	DateInEpochUTC   time.Time `json:"converted_date_utc"`
+	InputNumber      int64         `json:"input_number"`
+	InputValue       EpochNumber   `json:"input_value"`
+	EpochType        EpochType     `json:"epoch_type"`
+	Unit             EpochUnit     `json:"unit"`
+	DateInEpochLocal time.Time     `json:"converted_date_local"`
+	DateInEpochUTC   time.Time     `json:"converted_date_utc"`
+	Precision        time.Duration `json:"precision_ns"`            // Smallest step the input can express in this unit
+	DecodedValue     EpochNumber   `json:"decoded_value"`           // Count of units after unpacking by the epoch's Encoding
+	DateTimeKind     DateTimeKind  `json:"datetime_kind,omitempty"` // Only for .NET DateTime.ToBinary, from the top two bits
+	DatesInZones     []ZonedDate   `json:"converted_dates_in_zones,omitempty"`
+	Confidence       float64       `json:"confidence"` // From 0 to 1, this result's share of the Ranker's scores for the input
+}
+
+// ZonedDate is a converted date in a time zone, with the offset that was in force in the zone on that date.
+type ZonedDate struct {
+	Zone string    `json:"zone"` // IANA name of the zone, such as America/New_York
+	Date time.Time `json:"date"`
 }
 
 type EpochResults struct {
-	InputNumber    int64           `json:"input_number"`
+	InputNumber    int64           `json:"input_number"`         // Whole part of InputValue
+	InputText      string          `json:"input_text"`           // The number exactly as written, such as 01600000000
+	NormalizedText string          `json:"normalized,omitempty"` // InputText in ASCII digits, if written in others
+	Occurrences    int             `json:"occurrences"`          // Times InputText was given, see WithMergedDuplicates
+	InputValue     EpochNumber     `json:"input_value"`
+	InputBase      int             `json:"input_base"` // Base the input was written in, such as 16 for 0x5f5e1000
 	EpochTypes     EpochCollection `json:"epoch_types"`
-	AllResults     []epochResult   `json:"all_results"`
+	AllResults     []epochResult   `json:"all_results"` // Most likely first
 	MostLikelyType EpochType       `json:"most_likely_epoch"`
+	Confidence     float64         `json:"confidence"` // Confidence of the MostLikelyType
+	Ambiguous      bool            `json:"ambiguous"`  // Other results are nearly as likely as the MostLikelyType
+	TiedWith       []TiedResult    `json:"tied_with,omitempty"`
+	Verdict        Verdict         `json:"verdict"` // Whether the input is likely to be a timestamp at all
+	VerdictReason  string          `json:"verdict_reason"`
+	Position       Position        `json:"position"` // Where the input was found, empty for numbers not read from text
+}
+
+// TiedResult is a result nearly as likely as the most likely result, within the ambiguity margin. FAT and GPS, or
+// PostgreSQL and Mac OS X, start only days apart, so a number may fit both about as well.
+type TiedResult struct {
+	EpochType             EpochType `json:"epoch_type"`
+	Confidence            float64   `json:"confidence"`
+	DateInEpochUTC        time.Time `json:"converted_date_utc"`
+	SecondsFromMostLikely int64     `json:"seconds_from_most_likely"` // Positive when later than the most likely date
 }
 
 // Given a slice of strings, return a slice of EpochGuessResults type, each of which is an array of EpochResults along
 // with the most likely result. Strings in the input slice are parsed in the following way:
 // 1) Strings are stripped of leading and trailing whitespace characters.
-// 2) Strings have all data after the first dot character removed. This allows for input of decimal numbers
-//    Without needing to convert to floats.
+// 2) Strings with a 0x, 0o or 0b prefix are read as hex, octal or binary. A leading zero alone does not make octal.
+// 3) Other strings are read as base 10 numbers, which may have a decimal part after a dot. The decimal part is kept
+//    exactly, without converting to floats, so 44197.75 days is 18:00 on the day.
 // If one string that seemed to match a number cannot be converted, an Error is returned.
 // However, the numbers that were convertible are still returned. Ignore the error and continue, if desired.
 func GuessesForStrings(stringsToConvert []string) (epochResults []EpochResults, badStrings []string, err error) {
-	epochResults, badStrings, err = createGuesses(stringsToConvert, AllEpochs)
+	epochResults, badStrings, _, err = NewGuesser().GuessStrings(stringsToConvert)
 	return epochResults, badStrings, err
 }
 
-func createGuesses(stringsToConvert []string, collection EpochCollection) (epochResultsSlice []EpochResults,
-	badStrings []string, err error) {
+// GuessesForStringsInBase is GuessesForStrings for numbers written in a base, see ParseEpochNumberInBase. A base of 0
+// detects the base of each string, and a base of 16 reads bare hex like 5f5e1000.
+func GuessesForStringsInBase(stringsToConvert []string, base int) (epochResults []EpochResults, badStrings []string,
+	err error) {
+	epochResults, badStrings, _, err = NewGuesser(WithBase(base)).GuessStrings(stringsToConvert)
+	return epochResults, badStrings, err
+}
+
+// guessConfig holds what a guess is made with, besides the strings. NewGuesser sets the defaults.
+type guessConfig struct {
+	collection      EpochCollection
+	base            int              // Base numbers are read in, 0 to detect it
+	zones           []*time.Location // Zones to give each date in, besides Local and UTC
+	clock           Clock            // Numbers are ranked by closeness to its time, the SystemClock when nil
+	hint            period           // Numbers are ranked by closeness to this rather than the clock, unless it is zero
+	location        *time.Location   // Zone of DateInEpochLocal, time.Local when nil
+	units           []EpochUnit      // Units numbers may be counted in, DefaultUnits when nil
+	after           time.Time        // Dates before this are not guessed, unless it is zero
+	before          time.Time        // Dates after this are not guessed, unless it is zero
+	ranker          Ranker           // PriorWeighted when nil
+	minConfidence   float64          // Results less confident than this are left out
+	ambiguityMargin float64          // Results within this fraction of the most likely confidence are tied with it
+	mergeDuplicates bool             // Inputs written the same way are guessed once, and counted
+}
 
-	numbers, badStrings, err := stringSliceToInt64Base10s(stringsToConvert)
-	// Results array is as long as parsed numbers
-	epochResultsSlice = make([]EpochResults, len(numbers))
+// guessInputs guesses the epoch of each number. Numbers with no date in any epoch are added to the failures, which are
+// returned as badStrings and in a *ParseError, and those with dates that are none of them plausible are returned in
+// noMatch.
+func (c guessConfig) guessInputs(inputs []parsedInput, failures []ParseFailure) (
+	epochResultsSlice []EpochResults, badStrings []string, noMatch []string, err error) {
+	// read the clock once, so every number is ranked against the same instant.
+	now := clockOrSystem(c.clock).Now()
+	collection := c.candidates().AtTime(now)
+	reference := period{start: now, end: now}
+	if !c.hint.start.IsZero() {
+		reference = c.hint
+	}
+	if c.mergeDuplicates {
+		inputs = mergeDuplicates(inputs)
+	}
 	// loop through numbers and create epochs result data structures, which are an epoch type
 	// and the date in that epoch.
-	for i, n := range numbers {
-		epochResultsSlice[i].EpochTypes = collection
-		epochResultsSlice[i].InputNumber = n
+	for _, in := range inputs {
+		n := in.value
+		var epochResults EpochResults
+		epochResults.InputNumber = n.Int64()
+		epochResults.InputValue = n
+		epochResults.InputText = in.text()
+		if normalized := NormalizeDigits(in.text()); normalized != in.text() {
+			epochResults.NormalizedText = normalized
+		}
+		epochResults.Occurrences = in.occurrences
+		if epochResults.Occurrences == 0 {
+			epochResults.Occurrences = 1
+		}
+		epochResults.InputBase = in.base
+		epochResults.Position = in.position
+		converted := false
 		for _, et := range collection {
-			er := epochResult{InputNumber: n,
-				EpochType:        et,
-				DateInEpochLocal: et.DateForNumber(n, false),
-				DateInEpochUTC:   et.DateForNumber(n, true),
+			er, ok := c.resultFor(n, et)
+			if !ok {
+				continue
+			}
+			converted = true
+			if c.inWindow(er.DateInEpochUTC) {
+				epochResults.AllResults = append(epochResults.AllResults, er)
 			}
-			epochResultsSlice[i].AllResults = append(epochResultsSlice[i].AllResults, er)
 		}
-		// Run OrderedEpochsByClosestMatch on EC which takes a number and a time to match on.
-		for i, _ := range epochResultsSlice {
-			epochResultsSlice[i].EpochTypes = epochResultsSlice[i].EpochTypes.OrderedEpochsByClosestMatch(n, time.Now())
+		if !converted {
+			failures = append(failures, ParseFailure{Input: in.text(), Reason: ParseOutOfRange, Position: in.position})
+			continue
 		}
-		epochResultsSlice[i].MostLikelyType = epochResultsSlice[i].EpochTypes[0]
+		epochResults.AllResults, epochResults.EpochTypes = c.rank(epochResults.AllResults, reference, now)
+		epochResults.dropLessConfident(c.minConfidence)
+		if len(epochResults.AllResults) == 0 {
+			noMatch = append(noMatch, in.text())
+			continue
+		}
+		epochResults.MostLikelyType = epochResults.EpochTypes[0]
+		epochResults.Confidence = epochResults.AllResults[0].Confidence
+		epochResults.findTies(c.ambiguityMargin)
+		epochResults.Verdict, epochResults.VerdictReason = c.verdictFor(epochResults.AllResults[0],
+			reference.middle(), now)
+		epochResultsSlice = append(epochResultsSlice, epochResults)
+	}
+	sortFailures(failures)
+	switch parseErr := (&ParseError{Failures: failures}); {
+	case len(failures) > 0 && len(noMatch) > 0:
+		badStrings = parseErr.Inputs()
+		err = fmt.Errorf("%w; No plausible match for %s", parseErr, noMatch)
+	case len(failures) > 0:
+		badStrings = parseErr.Inputs()
+		err = parseErr
+	case len(noMatch) > 0:
+		err = fmt.Errorf("No plausible match for %s", noMatch)
 	}
-	return epochResultsSlice, badStrings, err
+	return epochResultsSlice, badStrings, noMatch, err
+}
+
+// rank ranks the results with the Ranker, putting those the verdict says are not timestamps after the rest with no
+// Confidence, so that a small count from the start of one epoch is not the most likely when another epoch gives a
+// plausible date. When no result is a timestamp, small counts are ranked first by how common their epochs are, see
+// rankSmallCounts, and then dates too far from now, by the Ranker.
+func (c guessConfig) rank(results []epochResult, reference period, now time.Time) ([]epochResult, EpochCollection) {
+	var plausible, smallCounts, farOff []epochResult
+	for _, er := range results {
+		switch verdict, _ := c.verdictFor(er, reference.middle(), now); {
+		case verdict != VerdictNotTimestamp:
+			plausible = append(plausible, er)
+		case er.isSmallCount():
+			smallCounts = append(smallCounts, er)
+		default:
+			farOff = append(farOff, er)
+		}
+	}
+	ranked, ecOut := rankResults(c.rankerOrDefault(), plausible, reference, now)
+	rejected, rejectedTypes := rankResults(c.rankerOrDefault(), append(smallCounts, farOff...), reference, now)
+	if len(ranked) == 0 && len(smallCounts) > 0 {
+		ranked, ecOut = rankSmallCounts(smallCounts, reference, now)
+		rejected, rejectedTypes = rankResults(c.rankerOrDefault(), farOff, reference, now)
+	}
+	if len(ranked) == 0 {
+		return rankResults(c.rankerOrDefault(), results, reference, now)
+	}
+	for i := range rejected {
+		rejected[i].Confidence = 0
+	}
+	return append(ranked, rejected...), append(ecOut, rejectedTypes...)
+}
+
+// candidates is the collection with every unbound epoch tried at each allowed unit, so milliseconds since Unix can
+// match Unix. When units are given, epochs bound to other units are left out.
+func (c guessConfig) candidates() (ecOut EpochCollection) {
+	if c.units == nil {
+		return c.collection.AtResolutions(DefaultUnits...)
+	}
+	allowed := make(map[EpochUnit]bool)
+	for _, unit := range c.units {
+		allowed[unit.effective()] = true
+	}
+	for _, et := range c.collection.AtResolutions(c.units...) {
+		if allowed[et.Unit.effective()] {
+			ecOut = append(ecOut, et)
+		}
+	}
+	return ecOut
+}
+
+// resultFor converts the number in the epoch, or returns false if the epoch cannot hold it.
+func (c guessConfig) resultFor(n EpochNumber, et EpochType) (er epochResult, ok bool) {
+	if !et.Encoding.applies(n) {
+		return er, false
+	}
+	// a number is not a plausible count of a unit if the date is out of range.
+	dateUTC, err := et.DateForValueIn(n, time.UTC)
+	if err != nil {
+		return er, false
+	}
+	dateLocal, err := et.DateForValueIn(n, c.locationOrLocal())
+	if err != nil {
+		return er, false
+	}
+	er = epochResult{InputNumber: n.Int64(),
+		InputValue:       n,
+		EpochType:        et,
+		Unit:             et.Unit,
+		DateInEpochLocal: dateLocal,
+		DateInEpochUTC:   dateUTC,
+		Precision:        n.Precision(et.Unit),
+	}
+	if er.DatesInZones, err = et.datesInZones(n, c.zones); err != nil {
+		return er, false
+	}
+	er.DecodedValue, _ = et.Encoding.decode(n)
+	if et.Encoding == EncodingDateTimeBinary {
+		bits, _ := n.bits64()
+		_, er.DateTimeKind = DecodeDateTimeBinary(int64(bits))
+	}
+	return er, true
+}
+
+// inWindow reports whether the date is within the window, where a zero time leaves that end open.
+func (c guessConfig) inWindow(date time.Time) bool {
+	if !c.after.IsZero() && date.Before(c.after) {
+		return false
+	}
+	return c.before.IsZero() || !date.After(c.before)
+}
+
+func (c guessConfig) locationOrLocal() *time.Location {
+	if c.location == nil {
+		return time.Local
+	}
+	return c.location
+}
+
+func (c guessConfig) rankerOrDefault() Ranker {
+	if c.ranker == nil {
+		return PriorWeighted
+	}
+	return c.ranker
+}
+
+// findTies lists the results whose confidence is within the margin, a fraction of the most likely result's confidence,
+// and marks the results Ambiguous if there are any. Results on the same instant as the most likely result are not
+// ties, since they agree on the date, as Microsoft COM and Excel do from 1900-03-01 on.
+func (ers *EpochResults) findTies(margin float64) {
+	mostLikely := ers.AllResults[0]
+	for _, er := range ers.AllResults[1:] {
+		if er.Confidence < mostLikely.Confidence*(1-margin) {
+			break
+		}
+		if er.DateInEpochUTC.Equal(mostLikely.DateInEpochUTC) {
+			continue
+		}
+		ers.TiedWith = append(ers.TiedWith, TiedResult{EpochType: er.EpochType,
+			Confidence:            er.Confidence,
+			DateInEpochUTC:        er.DateInEpochUTC,
+			SecondsFromMostLikely: er.DateInEpochUTC.Unix() - mostLikely.DateInEpochUTC.Unix(),
+		})
+	}
+	ers.Ambiguous = len(ers.TiedWith) > 0
+}
+
+// dropLessConfident removes the ranked results, and their epochs, that are less confident than minConfidence.
+func (ers *EpochResults) dropLessConfident(minConfidence float64) {
+	kept := 0
+	for kept < len(ers.AllResults) && ers.AllResults[kept].Confidence >= minConfidence {
+		kept++
+	}
+	ers.AllResults, ers.EpochTypes = ers.AllResults[:kept], ers.EpochTypes[:kept]
+}
+
+// datesInZones converts the number to a date in each of the zones.
+func (e *EpochType) datesInZones(value EpochNumber, zones []*time.Location) (dates []ZonedDate, err error) {
+	for _, zone := range zones {
+		date, err := e.DateForValueIn(value, zone)
+		if err != nil {
+			return nil, err
+		}
+		dates = append(dates, ZonedDate{Zone: zone.String(), Date: date})
+	}
+	return dates, nil
 }
 
 // OrderedEpochsByClosestMatch is a Method on an EpochCollection. Given an EpochCollection, typically AllEpochs,
 // return a collection order by closest match of an epoch number given a date to convert to all epoch seconds. Do not
 // alter the collection slice order in-place but, instead, return the sorted EpochCollection.
+// Each EpochType converts the number in its own Unit, and is ranked by how far that date is from matchToTime, so
+// the same number can be compared at different resolutions. Use AtResolutions to try every unit.
 // The first item in the returned Collection is the closest match, and matches are less likely at the end of the slice.
 //
 // Create your own EpochCollection by hand to add and remove existing or custom epochs.
 func (ec EpochCollection) OrderedEpochsByClosestMatch(number int64, matchToTime time.Time) (ecOut EpochCollection) {
+	return ec.OrderedEpochsByClosestValue(NewEpochNumber(number), matchToTime)
+}
+
+// OrderedEpochsByClosestValue is OrderedEpochsByClosestMatch for a number which may have a decimal part.
+func (ec EpochCollection) OrderedEpochsByClosestValue(value EpochNumber, matchToTime time.Time) (ecOut EpochCollection) {
+	return ec.OrderedEpochsByRanker(NearestToNow, value, matchToTime)
+}
+
+// OrderedEpochsByRanker is OrderedEpochsByClosestValue with the epochs ordered by a Ranker's score for the date the
+// number gives in each, highest first. Epochs with no date for the number, or which the ranker rules out, go to the
+// end of the list in order of when they started.
+func (ec EpochCollection) OrderedEpochsByRanker(ranker Ranker, value EpochNumber, reference time.Time) (
+	ecOut EpochCollection) {
 	// Do not sort the collection in place, return a new one.
 	sorted := make(EpochCollection, len(ec))
 	copy(sorted, ec)
-	sort.Sort(ByEpochDate(sorted))
-	// the return list is just as long as the original list
-	ecOut = make(EpochCollection, len(ec))
-
-	// make a slice just containing the epoch seconds for the input date
-	// the indices will match the indices of sorted. This is a convenience, and
-	// makes it simpler to accomplish ordering the list.
-	epochsOnly := make([]int64, len(ec))
+	sort.Stable(ByEpochDate(sorted))
+	scores := make([]float64, len(sorted))
 	for i, et := range sorted {
-		epochsOnly[i] = et.NumberForDate(matchToTime)
-	}
-	// distance stores how close the number is. Is a 2d array because it will store the original position after
-	// it is sorted, which can be examined to determine how to fill the final list.
-	epochDistances := make(epochDistances, len(ec))
-	for i, _ := range epochDistances {
-		// fill the distance slice
-		distance := epochsOnly[i] - number
-		// get abs this way, math.Abs means lots of float64 conversions.
-		if distance < 0 {
-			distance = distance * -1
+		date, err := et.DateForValue(value, true)
+		if err != nil {
+			continue
 		}
-		// preserve original array position
-		epochDistances[i] = []int64{distance, int64(i)}
+		scores[i] = ranker.Score(Candidate{EpochType: et, Value: value, Date: date}, reference)
 	}
-
-	// sort epoch distances. The second array element holds the original position.
-	sort.Sort(preserveSecondEl(epochDistances))
-	// insertionIndex := SearchInt64s(epochsOnly, number)
-	// at this point, the distances are sorted, and we can use the second dimension - the original position
-	// to determine what order the ecOut should be in
-	for i, distEntry := range epochDistances {
-		indexForEcOut := int(distEntry[1])
-		ecOut[i] = sorted[indexForEcOut]
+	order := make([]int, len(sorted))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+	ecOut = make(EpochCollection, len(sorted))
+	for i, index := range order {
+		ecOut[i] = sorted[index]
 	}
-
 	return ecOut
 }
 
-// accepts slice of strings, tries to clean them by removing common characters, and returns a list of int64s.
-func stringSliceToInt64Base10s(stringsToConvert []string) (numbers []int64, badStrings []string, err error) {
-	for _, s := range stringsToConvert {
-		s = strings.Trim(s, " \r\n\t")
-		//may have a period at the end, with numbers indicating ms. Strip this off.
-		s = strings.SplitN(s, ".", 1)[0]
-		num, cErr := getInt64Base10(s)
-		if cErr != nil {
-			badStrings = append(badStrings, s)
-		} else {
-			numbers = append(numbers, num)
-		}
-	}
-	if len(badStrings) > 0 {
-		err = fmt.Errorf("Some strings not converted, %s", badStrings)
-	}
-	return numbers, badStrings, err
+// parsedInput is a number read from an input string, along with what was learned reading it.
+type parsedInput struct {
+	value       EpochNumber
+	base        int
+	position    Position
+	occurrences int
 }
 
-func getInt64Base10(parseMe string) (number int64, err error) {
-	number, err = strconv.ParseInt(parseMe, 10, 64)
-	return number, err
+// text is the input exactly as written, or the number when it was not read from text.
+func (in parsedInput) text() string {
+	if in.position.Text != "" {
+		return in.position.Text
+	}
+	return in.value.String()
 }
 
-// a strange sort of way to sort a 2d array. It preserves the second array element's position.
-type epochDistances [][]int64
-type preserveSecondEl epochDistances
-
-func (a preserveSecondEl) Len() int {
-	return len(a)
-}
-func (a preserveSecondEl) Swap(i, j int) {
-	a[i], a[j] = a[j], a[i]
-}
-func (a preserveSecondEl) Less(i, j int) bool {
-	return a[i][0] < a[j][0]
+// mergeDuplicates merges inputs written the same way into the first of them, counting how many times each was given,
+// and keeping the order they were first given in.
+func mergeDuplicates(inputs []parsedInput) (merged []parsedInput) {
+	first := make(map[string]int)
+	for _, in := range inputs {
+		if i, seen := first[in.text()]; seen {
+			merged[i].occurrences++
+			continue
+		}
+		first[in.text()] = len(merged)
+		in.occurrences = 1
+		merged = append(merged, in)
+	}
+	return merged
 }
 
-// Given a slice of strings which could have integer data, create a new slice of only numbers in any of the strings
-func NumbersInStrings(stringsToClean []string) (numbersOnly []string){
-	re := regexp.MustCompile("[0-9]+")
-	numbersOnly = make([]string, 0)
-
-	for _, s := range stringsToClean {
-		nums := re.FindAllString(s, -1)
-		numbersOnly = append(numbersOnly, nums...)
+// accepts slice of strings, tries to clean them by removing common characters, and returns a list of parsed numbers.
+func stringSliceToEpochNumbers(stringsToConvert []string, base int) (inputs []parsedInput,
+	failures []ParseFailure) {
+	line := 1
+	for i, s := range stringsToConvert {
+		trimmed := strings.Trim(s, " \r\n\t")
+		start := strings.Index(s, trimmed)
+		position := positionIn(s, i, line, start, start+len(trimmed))
+		line += strings.Count(s, "\n") + 1
+		num, detectedBase, cErr := ParseEpochNumberInBase(trimmed, base)
+		if cErr != nil {
+			failures = append(failures, parseFailure(trimmed, position, cErr))
+		} else {
+			inputs = append(inputs, parsedInput{value: num, base: detectedBase, position: position})
+		}
 	}
-	return numbersOnly
+	return inputs, failures
 }
 
+// extractedToEpochNumbers is stringSliceToEpochNumbers for numbers found by ExtractNumbers, keeping their positions.
+func extractedToEpochNumbers(numbers []ExtractedNumber, base int) (inputs []parsedInput,
+	failures []ParseFailure) {
+	for _, extracted := range numbers {
+		num, detectedBase, err := ParseEpochNumberInBase(extracted.Number, base)
+		if err != nil {
+			failures = append(failures, parseFailure(extracted.Number, extracted.Position, err))
+		} else {
+			inputs = append(inputs, parsedInput{value: num, base: detectedBase, position: extracted.Position})
+		}
+	}
+	return inputs, failures
+}
 
@@ -1,11 +1,17 @@
 package epochconv
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"math/big"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unicode"
 )
 
 // used to determine the strings are parseable because particular errors are swallowed
@@ -14,10 +20,14 @@ var timeStringTests = []struct {
 	in EpochType
 }{
 	{EpochCommonEra},
+	{EpochDotNetTicks},
+	{EpochDotNetBinary},
 	{EpochWindowsEpoch},
+	{EpochWindowsFileTime},
 	{EpochVMS},
 	{EpochMicrosoftCOM},
 	{EpochMicrosoftExcel},
+	{EpochMicrosoftExcel1904},
 	{EpochNTP},
 	{EpochMacClassic},
 	{EpochUnix},
@@ -32,7 +42,7 @@ var timeStringTests = []struct {
 // respectively.
 func TestTimeStringsParseable(t *testing.T) {
 	for _, tt := range timeStringTests {
-		if tt.in.EpochDate.IsZero() && tt.in.EpochName != "CommonEra" { // This fails for CommonEra since it's 0
+		if tt.in.EpochDate.IsZero() && tt.in.EpochDateString != dateStringCommonEra { // This fails for CommonEra since it's 0
 			t.Errorf("Time was not initialized properly for Epoch Date %s, check format string constant used", tt.in.EpochName)
 		}
 		if tt.in.LocalRightNowInSecondsSince < 1 {
@@ -92,3 +102,1265 @@ func epochInSlice(s []EpochType, e EpochType) bool {
 	}
 	return false
 }
+
+// Tests whether sub-second resolutions are detected, and the unit reported with the most likely type.
+var unitGuessTests = []struct {
+	in   string
+	unit EpochUnit
+}{
+	{"1760000000", UnitSeconds},
+	{"1760000000000", UnitMilliseconds},
+	{"1760000000000000", UnitMicroseconds},
+	{"1760000000000000000", UnitNanoseconds},
+}
+
+func TestGuessesDetectUnit(t *testing.T) {
+	g := NewGuesser(WithClock(FixedClock(time.Date(2025, 10, 9, 0, 0, 0, 0, time.UTC))))
+	for _, tt := range unitGuessTests {
+		epochResults, _, _, err := g.GuessStrings([]string{tt.in})
+		if err != nil {
+			t.Fatalf("Could not guess %s: %s", tt.in, err)
+		}
+		mostLikely := epochResults[0].MostLikelyType
+		if mostLikely.EpochName != "Unix" || mostLikely.Unit != tt.unit {
+			t.Errorf("%s should be Unix in %s, got %s in %s", tt.in, tt.unit, mostLikely.EpochName, mostLikely.Unit)
+		}
+		for _, er := range epochResults[0].AllResults {
+			if er.Unit != er.EpochType.Unit || er.Unit == UnitUnspecified {
+				t.Errorf("Result for %s in %s has unit %s", tt.in, er.EpochType.EpochName, er.Unit)
+			}
+		}
+	}
+}
+
+// Tests whether every unbound epoch is expanded to each unit, without duplicating bound epochs.
+func TestAtResolutions(t *testing.T) {
+	bound := EpochUnix
+	bound.Unit = UnitMilliseconds
+	expanded := EpochCollection{EpochUnix, bound}.AtResolutions(DefaultUnits...)
+	if len(expanded) != len(DefaultUnits) {
+		t.Errorf("Expected %d epochs after expansion, got %d", len(DefaultUnits), len(expanded))
+	}
+	seen := make(map[EpochUnit]bool)
+	for _, et := range expanded {
+		if seen[et.Unit] {
+			t.Errorf("Unit %s appears more than once", et.Unit)
+		}
+		seen[et.Unit] = true
+	}
+}
+
+// Tests whether a number converts to the same date at every resolution when scaled.
+func TestDateForNumberUnits(t *testing.T) {
+	want := time.Date(2020, 9, 13, 12, 26, 40, 0, time.UTC)
+	for _, unit := range DefaultUnits {
+		et := EpochUnix
+		et.Unit = unit
+		n := int64(1600000000) * unit.perSecond()
+		if got, err := et.DateForNumber(n, true); err != nil || !got.Equal(want) {
+			t.Errorf("%d %s should be %s, got %s %v", n, unit, want, got, err)
+		}
+		if got, err := et.NumberForDate(want); err != nil || got != n {
+			t.Errorf("%s should be %d %s, got %d %v", want, n, unit, got, err)
+		}
+	}
+}
+
+// Tests whether Windows and .NET tick counts are matched to their tick based epochs.
+var tickGuessTests = []struct {
+	in   string
+	name string
+	want time.Time
+	kind DateTimeKind
+}{
+	// FILETIME and DateTime.Ticks for 2025-06-01T12:00:00Z
+	{"133932528000000000", "Windows FILETIME", time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), DateTimeKindUnspecified},
+	{"638843760000000000", ".NET DateTime Ticks", time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), DateTimeKindUnspecified},
+	// DateTime.ToBinary of the same instant, with kind Utc and kind Local
+	{"5250529778427387904", ".NET DateTime Binary", time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), DateTimeKindUtc},
+	{"-8584528276854775808", ".NET DateTime Binary", time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), DateTimeKindLocal},
+	// DateTime.Ticks in 2019, which is also Mac OS X nanoseconds in 2021, a unit Mac OS X does not count in
+	{"637000000000000000", ".NET DateTime Ticks", time.Date(2019, 7, 29, 12, 26, 40, 0, time.UTC), DateTimeKindUnspecified},
+}
+
+func TestGuessesTicks(t *testing.T) {
+	g := NewGuesser(WithClock(FixedClock(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))))
+	for _, tt := range tickGuessTests {
+		epochResults, _, _, err := g.GuessStrings([]string{tt.in})
+		if err != nil {
+			t.Fatalf("Could not guess %s: %s", tt.in, err)
+		}
+		mostLikely := epochResults[0].MostLikelyType
+		if mostLikely.EpochName != tt.name {
+			t.Errorf("%s should be %s, got %s in %s", tt.in, tt.name, mostLikely.EpochName, mostLikely.Unit)
+			continue
+		}
+		for _, er := range epochResults[0].AllResults {
+			if er.EpochType.EpochName != tt.name {
+				continue
+			}
+			if !er.DateInEpochUTC.Equal(tt.want) {
+				t.Errorf("%s should be %s, got %s", tt.in, tt.want, er.DateInEpochUTC)
+			}
+			if er.DateTimeKind != tt.kind {
+				t.Errorf("%s should be kind %s, got %s", tt.in, tt.kind, er.DateTimeKind)
+			}
+		}
+	}
+}
+
+// Tests whether Local DateTime.ToBinary values that wrapped below zero are unwrapped.
+func TestDecodeDateTimeBinaryWrapped(t *testing.T) {
+	// .NET stores ticks - offset, wrapped into 62 bits, when that is negative.
+	binary := int64(-1<<63) | (dateTimeTicksCeiling - 36000000000)
+	ticks, kind := DecodeDateTimeBinary(binary)
+	if kind != DateTimeKindLocal || ticks != -36000000000 {
+		t.Errorf("Expected -36000000000 Local ticks, got %d %s", ticks, kind)
+	}
+}
+
+// Tests whether spreadsheet serial days keep their decimal part as the time of day, and model the Excel date systems.
+var serialDayTests = []struct {
+	in    string
+	epoch EpochType
+	want  time.Time
+}{
+	{"44197.75", EpochMicrosoftCOM, time.Date(2021, 1, 1, 18, 0, 0, 0, time.UTC)},
+	{"44197.75", EpochMicrosoftExcel, time.Date(2021, 1, 1, 18, 0, 0, 0, time.UTC)},
+	{"42765.5", EpochMicrosoftExcel1904, time.Date(2021, 1, 31, 12, 0, 0, 0, time.UTC)},
+	{"1", EpochMicrosoftExcel, time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)},
+	{"59", EpochMicrosoftExcel, time.Date(1900, 2, 28, 0, 0, 0, 0, time.UTC)},
+	// 60 is 1900-02-29 to Excel, a day that did not happen
+	{"60.25", EpochMicrosoftExcel, time.Date(1900, 2, 28, 6, 0, 0, 0, time.UTC)},
+	{"61", EpochMicrosoftExcel, time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC)},
+	{"1", EpochMicrosoftCOM, time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC)},
+	{"-1.25", EpochMicrosoftCOM, time.Date(1899, 12, 28, 18, 0, 0, 0, time.UTC)},
+	// more digits than an int64 holds
+	{"44197.750000000000000000", EpochMicrosoftExcel, time.Date(2021, 1, 1, 18, 0, 0, 0, time.UTC)},
+	{"1600000000.5", EpochUnix, time.Date(2020, 9, 13, 12, 26, 40, 5e8, time.UTC)},
+}
+
+func TestDateForValueSerialDays(t *testing.T) {
+	for _, tt := range serialDayTests {
+		value, err := ParseEpochNumber(tt.in)
+		if err != nil {
+			t.Fatalf("Could not parse %s: %s", tt.in, err)
+		}
+		if got, err := tt.epoch.DateForValue(value, true); err != nil || !got.Equal(tt.want) {
+			t.Errorf("%s in %s should be %s, got %s %v", tt.in, tt.epoch.EpochName, tt.want, got, err)
+		}
+		if value.IsInteger() {
+			if got, err := tt.epoch.NumberForDate(tt.want); err != nil || got != value.Mantissa {
+				t.Errorf("%s in %s should be %s, got %d %v", tt.want, tt.epoch.EpochName, tt.in, got, err)
+			}
+		}
+	}
+}
+
+// Tests whether spreadsheet serials, with or without a time of day, are guessed as serial days in the 1900 date
+// system rather than the four years later 1904 one.
+var serialDayGuessTests = []struct {
+	in   string
+	want time.Time
+}{
+	{"45000.125", time.Date(2023, 3, 15, 3, 0, 0, 0, time.UTC)},
+	{"44197.75", time.Date(2021, 1, 1, 18, 0, 0, 0, time.UTC)},
+	{"45000", time.Date(2023, 3, 15, 0, 0, 0, 0, time.UTC)},
+}
+
+func TestGuessesSerialDays(t *testing.T) {
+	at := FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+	for _, tt := range serialDayGuessTests {
+		epochResults, _, _, err := NewGuesser(WithClock(at)).GuessStrings([]string{tt.in})
+		if err != nil {
+			t.Fatalf("Could not guess serial days: %s", err)
+		}
+		best := epochResults[0].AllResults[0]
+		if best.Unit != UnitDays || !best.DateInEpochUTC.Equal(tt.want) {
+			t.Errorf("%s should be serial days on %s, got %s in %s on %s", tt.in, tt.want, best.EpochType.EpochName,
+				best.Unit, best.DateInEpochUTC)
+		}
+		if epochResults[0].InputValue.String() != tt.in {
+			t.Errorf("Input was not kept exactly, got %s", epochResults[0].InputValue)
+		}
+	}
+	// by distance alone, the 1904 date two weeks ago rather than the 1900 one four years before it.
+	epochResults, _, _, _ := NewGuesser(WithClock(at), WithRanker(NearestToNow)).GuessStrings([]string{"44834.5"})
+	want := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	if got := epochResults[0].AllResults[0]; got.EpochType.EpochName != EpochMicrosoftExcel1904.EpochName ||
+		!got.DateInEpochUTC.Equal(want) {
+		t.Errorf("44834.5 should be Excel 1904 serial days on %s, got %s on %s", want, got.EpochType.EpochName,
+			got.DateInEpochUTC)
+	}
+}
+
+// Tests whether conversions across the whole int64 range are exact, or return ErrOutOfRange rather than wrapping.
+var int64RangeTests = []struct {
+	number int64
+	epoch  EpochType
+	unit   EpochUnit
+	want   time.Time
+}{
+	{math.MaxInt64, EpochUnix, UnitNanoseconds, time.Date(2262, 4, 11, 23, 47, 16, 854775807, time.UTC)},
+	{math.MinInt64, EpochUnix, UnitNanoseconds, time.Date(1677, 9, 21, 0, 12, 43, 145224192, time.UTC)},
+	{math.MaxInt64, EpochCommonEra, UnitTicks, time.Date(29228, 1, 1, 0, 0, 0, 0, time.UTC)},
+	{math.MaxInt64, EpochUnix, UnitSeconds, time.Time{}},
+	{math.MinInt64, EpochWindowsEpoch, UnitMilliseconds, time.Time{}},
+	{253402300799, EpochUnix, UnitSeconds, time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)},
+	{253402300800, EpochUnix, UnitSeconds, time.Time{}},
+	{3155378975999999999, EpochCommonEra, UnitTicks, time.Date(9999, 12, 31, 23, 59, 59, 999999900, time.UTC)},
+}
+
+func TestDateForNumberInt64Range(t *testing.T) {
+	for _, tt := range int64RangeTests {
+		et := tt.epoch
+		et.Unit = tt.unit
+		got, err := et.DateForNumber(tt.number, true)
+		if tt.want.Year() > MaxSupportedDate.Year() || tt.want.IsZero() {
+			if !errors.Is(err, ErrOutOfRange) {
+				t.Errorf("%d %s in %s should be out of range, got %s %v", tt.number, tt.unit, et.EpochName, got, err)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("%d %s in %s should be %s, got %s %v", tt.number, tt.unit, et.EpochName, tt.want, got, err)
+		}
+	}
+}
+
+// Tests whether dates are counted exactly in units too large for an int64.
+func TestNumberForDateOverflow(t *testing.T) {
+	et := EpochCommonEra
+	et.Unit = UnitNanoseconds
+	date := time.Date(2020, 9, 13, 12, 26, 40, 123456789, time.UTC)
+	if _, err := et.NumberForDate(date); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("Nanoseconds since the common era should not fit in an int64, got %v", err)
+	}
+	want, _ := new(big.Int).SetString("63735596800123456789", 10)
+	got := et.BigNumberForDate(date)
+	if got.Cmp(want) != 0 {
+		t.Errorf("Expected %s nanoseconds since the common era, got %s", want, got)
+	}
+	if back, err := et.DateForBigNumber(got, true); err != nil || !back.Equal(date) {
+		t.Errorf("Expected %s back from %s, got %s %v", date, got, back, err)
+	}
+}
+
+// Tests whether fractional seconds from common tools convert without loss, and their precision is reported.
+var fractionalSecondsTests = []struct {
+	in        string
+	epoch     EpochType
+	want      time.Time
+	precision time.Duration
+}{
+	// strace -ttt
+	{"1600000000.123456", EpochUnix, time.Date(2020, 9, 13, 12, 26, 40, 123456000, time.UTC), time.Microsecond},
+	// Python time.time()
+	{"1600000000.1234567", EpochUnix, time.Date(2020, 9, 13, 12, 26, 40, 123456700, time.UTC), 100 * time.Nanosecond},
+	// Cocoa NSDate timeIntervalSinceReferenceDate
+	{"621697600.987654", EpochMacOSX, time.Date(2020, 9, 13, 13, 46, 40, 987654000, time.UTC), time.Microsecond},
+	{"1600000000.123456789", EpochUnix, time.Date(2020, 9, 13, 12, 26, 40, 123456789, time.UTC), time.Nanosecond},
+	// digits past a nanosecond are kept in the number, but not in the date
+	{"1600000000.12345678912345", EpochUnix, time.Date(2020, 9, 13, 12, 26, 40, 123456789, time.UTC), time.Nanosecond},
+}
+
+func TestFractionalSeconds(t *testing.T) {
+	for _, tt := range fractionalSecondsTests {
+		epochResults, _, err := EpochCollection{tt.epoch}.GuessesForStrings([]string{tt.in})
+		if err != nil {
+			t.Fatalf("Could not guess %s: %s", tt.in, err)
+		}
+		for _, er := range epochResults[0].AllResults {
+			if er.Unit != UnitSeconds {
+				continue
+			}
+			if !er.DateInEpochUTC.Equal(tt.want) {
+				t.Errorf("%s should be %s, got %s", tt.in, tt.want, er.DateInEpochUTC.Format(time.RFC3339Nano))
+			}
+			if er.Precision != tt.precision {
+				t.Errorf("%s should be precise to %s, got %s", tt.in, tt.precision, er.Precision)
+			}
+		}
+	}
+}
+
+// Tests whether the base of a number is detected from its prefix, or taken from the caller.
+var baseParseTests = []struct {
+	in   string
+	base int
+	want int64
+	read int
+}{
+	{"0x5f5e1000", 0, 1600000000, 16},
+	{"0X5F5E1000", 0, 1600000000, 16},
+	{"0o13727410000", 0, 1600000000, 8},
+	{"013727410000", 0, 13727410000, 10},
+	{"013727410000", 8, 1600000000, 8},
+	{"0b1011111010111100001000000000000", 0, 1600000000, 2},
+	{"1600000000", 0, 1600000000, 10},
+	{"089", 0, 89, 10},
+	{"5f5e1000", 16, 1600000000, 16},
+	{"0x5f5e1000", 16, 1600000000, 16},
+	{"0b1f", 16, 0xb1f, 16},
+	{"13727410000", 8, 1600000000, 8},
+}
+
+func TestParseEpochNumberInBase(t *testing.T) {
+	for _, tt := range baseParseTests {
+		got, read, err := ParseEpochNumberInBase(tt.in, tt.base)
+		if err != nil || got.Mantissa != tt.want || !got.IsInteger() || read != tt.read {
+			t.Errorf("%s in base %d should be %d read as base %d, got %s in base %d %v", tt.in, tt.base, tt.want,
+				tt.read, got, read, err)
+		}
+	}
+	for _, bad := range []string{"0x5f.5", "0b102", "0x", "5f5e1000", "0x-10", "0b+1", "-0x-10"} {
+		if _, _, err := ParseEpochNumberInBase(bad, 0); err == nil {
+			t.Errorf("%s should not parse with a detected base", bad)
+		}
+	}
+}
+
+// Tests whether prefixed numbers are extracted whole, and their base is kept with the result.
+func TestNumbersInStringsBases(t *testing.T) {
+	found := NumbersInStrings([]string{"mtime=0x5f5e1000, mode 0o755 flags=0b101 size 12.5"})
+	want := []string{"0x5f5e1000", "0o755", "0b101", "12.5"}
+	if !reflect.DeepEqual(found, want) {
+		t.Errorf("Expected %v, got %v", want, found)
+	}
+	hex := NumbersInStringsInBase([]string{"0000: 5f5e1000 0x00000010"}, 16)
+	if !reflect.DeepEqual(hex, []string{"0000", "5f5e1000", "0x00000010"}) {
+		t.Errorf("Expected bare hex to be extracted, got %v", hex)
+	}
+	at := FixedClock(time.Date(2020, 9, 13, 0, 0, 0, 0, time.UTC))
+	epochResults, _, _, err := NewGuesser(WithClock(at)).GuessStrings([]string{"0x5f5e1000"})
+	if err != nil || epochResults[0].InputBase != 16 || epochResults[0].InputNumber != 1600000000 {
+		t.Errorf("Expected 1600000000 read as base 16, got %v %v", epochResults, err)
+	}
+}
+
+// Tests whether numbers in a base are found only as whole words, so the letters of words are not read as hex.
+var baseExtractTests = []struct {
+	in   string
+	base int
+	want []string
+}{
+	{"mtime 5f5e1000 for file header", 16, []string{"5f5e1000"}},
+	{"a bad face at 0x5f5e1000, added", 16, []string{"0x5f5e1000"}},
+	{"mode 0755 in file2 or 0o644", 8, []string{"0755", "0o644"}},
+	{"flags 0b101 and 1101, not 2101", 2, []string{"0b101", "1101"}},
+}
+
+func TestExtractNumbersInBase(t *testing.T) {
+	for _, tt := range baseExtractTests {
+		var found []string
+		for _, extracted := range ExtractNumbers([]string{tt.in}, tt.base) {
+			found = append(found, extracted.Number)
+			if extracted.Position.Text != extracted.Number {
+				t.Errorf("Expected %s to be found as written, got %q", extracted.Number, extracted.Position.Text)
+			}
+		}
+		if !reflect.DeepEqual(found, tt.want) {
+			t.Errorf("Expected %q in %q in base %d, got %q", tt.want, tt.in, tt.base, found)
+		}
+	}
+}
+
+// Tests whether digits that are part of something else are skipped, and digit groups, signs and decimals are kept.
+var numbersInContextTests = []struct {
+	in   string
+	want []string
+}{
+	{"from 192.168.1.10 port 8080", []string{"8080"}},
+	{"release v1.2.3 and 1.4.0-rc1, was v2.0", []string{}},
+	{"id 550e8400-e29b-41d4-a716-446655440000 hash a3f9c2 at 1600000000", []string{"1600000000"}},
+	{"on 2021-03-04T10:11:12Z and 2021-03-05", []string{}},
+	{"1,600,000,000 or 1_600_000_000.25", []string{"1600000000", "1600000000.25"}},
+	{"1\u2009600\u2009000\u2009000", []string{"1600000000"}},
+	{"1,2,3 and 12,345,6789", []string{"1", "2", "3", "12", "345", "6789"}},
+	{"offset=-3600 shift +42 range a-5", []string{"-3600", "+42", "5"}},
+	{"1600000000ms", []string{"1600000000"}},
+	{"offset \u22123600, a\u22125 and \u2212 1", []string{"-3600", "5", "1"}},
+	{"rate 1.5e9 or 1.6E+09 and 2e-3", []string{}},
+	{"at 12:30:45 and 09:05, took 250", []string{"250"}},
+	{"on 2021/03/04 or 4/3/2021 at 12:30:45.123", []string{}},
+	{"ratio 16:9 on host:8080, pid 1600000000:12", []string{"16", "9", "8080", "1600000000", "12"}},
+}
+
+func TestNumbersInStringsContext(t *testing.T) {
+	for _, tt := range numbersInContextTests {
+		found := NumbersInStrings([]string{tt.in})
+		if !reflect.DeepEqual(found, tt.want) {
+			t.Errorf("Expected %q in %q, got %q", tt.want, tt.in, found)
+		}
+	}
+}
+
+// Tests whether numbers read in the wrong byte order are found when byte swaps are asked for.
+var byteSwapTests = []struct {
+	in       string
+	encoding NumberEncoding
+}{
+	{"7923560", EncodingByteSwapped32},
+	{"34031431067893760", EncodingByteSwapped64},
+}
+
+func TestGuessesByteSwapped(t *testing.T) {
+	want := time.Unix(1760000000, 0).UTC()
+	at := WithClock(FixedClock(want))
+	swaps := NewGuesser(at, WithCollection(AllEpochs.WithByteSwaps()))
+	for _, tt := range byteSwapTests {
+		epochResults, _, _, err := swaps.GuessStrings([]string{tt.in})
+		if err != nil {
+			t.Fatalf("Could not guess %s: %s", tt.in, err)
+		}
+		mostLikely := epochResults[0].MostLikelyType
+		if mostLikely.EpochName != "Unix" || mostLikely.Encoding != tt.encoding {
+			t.Errorf("%s should be %s, got %s", tt.in, tt.encoding, mostLikely.Label())
+		}
+		date, err := mostLikely.DateForNumber(epochResults[0].InputNumber, true)
+		if err != nil || !date.Equal(want) {
+			t.Errorf("%s should be %s, got %s %v", tt.in, want, date, err)
+		}
+		if label := mostLikely.Label(); !strings.Contains(label, "byte-swapped, little-endian") {
+			t.Errorf("Byte swapped result should be labelled, got %s", label)
+		}
+		plain, _, _, _ := NewGuesser(at).GuessStrings([]string{tt.in})
+		if plain[0].MostLikelyType.Encoding != EncodingPlain {
+			t.Errorf("%s should not be byte swapped unless asked for", tt.in)
+		}
+	}
+	et := EpochUnix
+	et.Encoding = EncodingByteSwapped32
+	if _, err := et.DateForNumber(1<<32, true); !errors.Is(err, ErrWrongEncoding) {
+		t.Errorf("A number wider than 32 bits should not be byte swapped as 32 bits, got %v", err)
+	}
+}
+
+// Tests whether numbers whose bytes read the same either way round, including those too large for an int64, are not
+// tried byte-swapped as well, since the plain result already covers them.
+var byteSymmetricTests = []struct {
+	in       string
+	encoding NumberEncoding
+}{
+	{"16777217", EncodingByteSwapped32},
+	{"4755801206503243842", EncodingByteSwapped64},
+	{"9295429630892703873", EncodingByteSwapped64},
+}
+
+func TestByteSymmetricNotSwapped(t *testing.T) {
+	for _, tt := range byteSymmetricTests {
+		number, err := ParseEpochNumber(tt.in)
+		if err != nil {
+			t.Fatalf("Could not parse %s: %s", tt.in, err)
+		}
+		if tt.encoding.applies(number) {
+			t.Errorf("Expected %s not to be tried %s, as it is the same either way round", tt.in, tt.encoding)
+		}
+	}
+}
+
+// Tests whether dates in a zone take the offset in force on the date, rather than today's offset.
+var zoneTests = []struct {
+	zone string
+	in   int64
+	want string
+}{
+	{"America/New_York", 1720000000, "2024-07-03T05:46:40-04:00"}, // daylight saving time
+	{"America/New_York", 1700000000, "2023-11-14T17:13:20-05:00"}, // standard time
+	{"Europe/London", 0, "1970-01-01T01:00:00+01:00"},             // British Standard Time, 1968 to 1971
+	{"Asia/Kolkata", -800000000, "1944-08-26T00:16:40+06:30"},     // war time
+	{"Australia/Sydney", 1720000000, "2024-07-03T19:46:40+10:00"}, // southern winter
+}
+
+func TestDateForNumberInZone(t *testing.T) {
+	for _, tt := range zoneTests {
+		zone, err := time.LoadLocation(tt.zone)
+		if err != nil {
+			t.Skipf("No zone database for %s: %s", tt.zone, err)
+		}
+		date, err := EpochUnix.DateForNumberIn(tt.in, zone)
+		if err != nil {
+			t.Fatalf("Could not convert %d in %s: %s", tt.in, tt.zone, err)
+		}
+		if got := date.Format(time.RFC3339); got != tt.want {
+			t.Errorf("%d in %s should be %s, got %s", tt.in, tt.zone, tt.want, got)
+		}
+	}
+	zones := []*time.Location{time.UTC}
+	if zone, err := time.LoadLocation("America/New_York"); err == nil {
+		zones = append(zones, zone)
+	}
+	epochResults, _, err := EpochCollection{EpochUnix}.GuessesForStringsInZones([]string{"1720000000"}, 0, zones)
+	if err != nil {
+		t.Fatalf("Could not guess in zones: %s", err)
+	}
+	for _, er := range epochResults[0].AllResults {
+		if len(er.DatesInZones) != len(zones) {
+			t.Fatalf("Expected a date in each of %d zones, got %v", len(zones), er.DatesInZones)
+		}
+		for i, zd := range er.DatesInZones {
+			if zd.Zone != zones[i].String() || !zd.Date.Equal(er.DateInEpochUTC) {
+				t.Errorf("Expected %s in %s, got %s in %s", er.DateInEpochUTC, zones[i], zd.Date, zd.Zone)
+			}
+		}
+	}
+}
+
+// Tests whether numbers are ranked against the clock's time, and the RightNow fields are counted to it.
+var clockTests = []struct {
+	at   time.Time
+	in   string
+	want string
+}{
+	{time.Date(2001, 9, 9, 0, 0, 0, 0, time.UTC), "1000000000", "Unix"},
+	{time.Date(2032, 9, 9, 0, 0, 0, 0, time.UTC), "1000000000", "Mac OS X"},
+}
+
+func TestGuessesAtClock(t *testing.T) {
+	for _, tt := range clockTests {
+		epochResults, _, err := AllEpochs.GuessesForStringsAt([]string{tt.in}, 0, nil, FixedClock(tt.at))
+		if err != nil {
+			t.Fatalf("Could not guess %s: %s", tt.in, err)
+		}
+		mostLikely := epochResults[0].MostLikelyType
+		if mostLikely.EpochName != tt.want {
+			t.Errorf("%s at %s should be %s, got %s", tt.in, tt.at, tt.want, mostLikely.Label())
+		}
+		if want := tt.at.Unix() - mostLikely.EpochDate.Unix(); mostLikely.UTCRightNowInSecondsSince != want {
+			t.Errorf("%s should be %d seconds in at %s, got %d", mostLikely.EpochName, want, tt.at,
+				mostLikely.UTCRightNowInSecondsSince)
+		}
+	}
+}
+
+// Tests whether each Guesser option changes how numbers are guessed.
+func TestGuesserOptions(t *testing.T) {
+	at := FixedClock(time.Date(2025, 10, 9, 0, 0, 0, 0, time.UTC))
+	g := NewGuesser(WithCollection(EpochCollection{EpochUnix}), WithClock(at), WithUnits(UnitMilliseconds))
+	epochResults, badStrings, _, err := g.GuessStrings([]string{"1760000000000"})
+	if err != nil || len(badStrings) != 0 {
+		t.Fatalf("Could not guess milliseconds: %v %s", badStrings, err)
+	}
+	if len(epochResults[0].AllResults) != 1 || epochResults[0].MostLikelyType.Unit != UnitMilliseconds {
+		t.Errorf("Expected only Unix milliseconds, got %v", epochResults[0].EpochTypes)
+	}
+
+	window := NewGuesser(WithClock(at), WithWindow(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	epochResults, badStrings, noMatch, err := window.GuessInt64s([]int64{1760000000, 42})
+	if err == nil || len(badStrings) != 0 || !reflect.DeepEqual(noMatch, []string{"42"}) {
+		t.Errorf("Expected 42 to have no date in the window, got %v %v %v", badStrings, noMatch, err)
+	}
+	for _, er := range epochResults[0].AllResults {
+		if er.DateInEpochUTC.Year() != 2025 {
+			t.Errorf("Expected only dates in 2025, got %s for %s", er.DateInEpochUTC, er.EpochType.Label())
+		}
+	}
+
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	epochResults, _, _, _ = NewGuesser(WithClock(at), WithLocation(loc)).GuessInt64s([]int64{1760000000})
+	if local := epochResults[0].AllResults[0].DateInEpochLocal; local.Location() != loc {
+		t.Errorf("Expected the local date in %s, got %s", loc, local)
+	}
+
+	tooBig, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	epochResults, badStrings, _, _ = NewGuesser(WithClock(at)).GuessBigInts([]*big.Int{big.NewInt(1760000000), tooBig})
+	if len(epochResults) != 1 || !reflect.DeepEqual(badStrings, []string{tooBig.String()}) {
+		t.Errorf("Expected only the int64 to be guessed, got %d results and %v", len(epochResults), badStrings)
+	}
+
+	epochResults, _, _, err = NewGuesser(WithClock(at)).GuessReader(
+		strings.NewReader("started at 1760000000\nended at 1760000100\n"))
+	if err != nil || len(epochResults) != 2 || epochResults[1].InputNumber != 1760000100 {
+		t.Errorf("Expected both numbers from the reader, got %v %v", epochResults, err)
+	}
+}
+
+// Tests whether a Guesser shared by several goroutines gives each the same guesses.
+func TestGuesserConcurrent(t *testing.T) {
+	g := NewGuesser(WithClock(FixedClock(time.Date(2025, 10, 9, 0, 0, 0, 0, time.UTC))))
+	inputs := []string{"1760000000", "1760000000000", "44197.75", "0x5f5e1000"}
+	want, _, _, _ := g.GuessStrings(inputs)
+	var wg sync.WaitGroup
+	got := make([][]EpochResults, 8)
+	for i := range got {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i], _, _, _ = g.GuessStrings(inputs)
+		}(i)
+	}
+	wg.Wait()
+	for i := range got {
+		if !reflect.DeepEqual(got[i], want) {
+			t.Errorf("Goroutine %d got different guesses", i)
+		}
+	}
+}
+
+// Tests whether results carry confidences which sum to one, and are sorted by them.
+func TestConfidence(t *testing.T) {
+	at := FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+	epochResults, _, _, err := NewGuesser(WithClock(at)).GuessStrings([]string{"1600000000"})
+	if err != nil {
+		t.Fatalf("Could not guess: %s", err)
+	}
+	ers := epochResults[0]
+	total := 0.0
+	for i, er := range ers.AllResults {
+		total += er.Confidence
+		if i > 0 && er.Confidence > ers.AllResults[i-1].Confidence {
+			t.Errorf("Result %d is more confident than the one before it", i)
+		}
+		if ers.EpochTypes[i].Label() != er.EpochType.Label() {
+			t.Errorf("EpochTypes should be in the order of AllResults, got %s at %d", ers.EpochTypes[i].Label(), i)
+		}
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("Confidences should sum to 1, got %f", total)
+	}
+	if ers.MostLikelyType.Label() != "Unix, seconds" || ers.Confidence != ers.AllResults[0].Confidence {
+		t.Errorf("Expected Unix seconds, got %s with confidence %f", ers.MostLikelyType.Label(), ers.Confidence)
+	}
+	// distance alone puts FAT in 2030 closer than Unix in 2020.
+	epochResults, _, _, _ = NewGuesser(WithClock(at), WithRanker(NearestToNow)).GuessStrings([]string{"1600000000"})
+	if epochResults[0].MostLikelyType.EpochName != "FAT" {
+		t.Errorf("Expected FAT nearest to now, got %s", epochResults[0].MostLikelyType.Label())
+	}
+	// GPS is five days after FAT, so is nearer, and they are as prevalent.
+	epochResults, _, _, _ = NewGuesser(WithClock(at)).GuessStrings([]string{"1476000000"})
+	if epochResults[0].MostLikelyType.EpochName != "GPS" {
+		t.Errorf("Expected GPS nearer, got %s", epochResults[0].MostLikelyType.Label())
+	}
+	// Unix in 2010 rather than FAT in 2020.
+	epochResults, _, _, _ = NewGuesser(WithClock(at)).GuessStrings([]string{"1262304000"})
+	if epochResults[0].MostLikelyType.Label() != "Unix, seconds" {
+		t.Errorf("Expected Unix seconds by prevalence, got %s", epochResults[0].MostLikelyType.Label())
+	}
+
+	epochResults, _, noMatch, _ := NewGuesser(WithClock(at), WithMinConfidence(0.2)).GuessStrings(
+		[]string{"1600000000", "1000"})
+	if len(epochResults) != 1 || !reflect.DeepEqual(noMatch, []string{"1000"}) {
+		t.Fatalf("Expected 1000 to have no confident match, got %v", noMatch)
+	}
+	for _, er := range epochResults[0].AllResults {
+		if er.Confidence < 0.2 {
+			t.Errorf("Expected only results of 20%% or more, got %s at %f", er.EpochType.Label(), er.Confidence)
+		}
+	}
+}
+
+// Tests whether each built in Ranker can be found by name, and ranks as it says.
+func TestRankers(t *testing.T) {
+	at := FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+	hint := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	rankerTests := []struct {
+		name string
+		want string
+	}{
+		{RankerNearest, "FAT"},
+		{RankerPast, "Unix"},
+		{RankerPrior, "Unix"},
+		{RankerHint, "FAT"},
+	}
+	for _, tt := range rankerTests {
+		ranker, err := RankerByName(tt.name, hint)
+		if err != nil {
+			t.Fatalf("Could not find ranker %s: %s", tt.name, err)
+		}
+		epochResults, _, _, _ := NewGuesser(WithClock(at), WithRanker(ranker)).GuessStrings([]string{"1600000000"})
+		if got := epochResults[0].MostLikelyType.EpochName; got != tt.want {
+			t.Errorf("Ranker %s should pick %s, got %s", tt.name, tt.want, got)
+		}
+		if tt.name != RankerPast {
+			continue
+		}
+		for _, er := range epochResults[0].AllResults {
+			if er.DateInEpochUTC.After(at.Now()) {
+				t.Errorf("Ranker %s should rule out %s in the future", tt.name, er.EpochType.Label())
+			}
+		}
+	}
+	if _, err := RankerByName(RankerHint, time.Time{}); err == nil {
+		t.Error("The hint ranker should need a date")
+	}
+	if _, err := RankerByName("bogus", hint); err == nil {
+		t.Error("An unknown ranker should be an error")
+	}
+	ordered := AllEpochs.OrderedEpochsByRanker(NearestTo(hint), NewEpochNumber(1600000000), at.Now())
+	if ordered[0].EpochName != "FAT" || len(ordered) != len(AllEpochs) {
+		t.Errorf("Expected FAT first of all epochs, got %s of %d", ordered[0].EpochName, len(ordered))
+	}
+}
+
+// Tests whether results nearly as likely as the most likely are listed as ties.
+func TestAmbiguity(t *testing.T) {
+	at := FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+	spreadsheets := WithCollection(EpochCollection{EpochMicrosoftCOM, EpochMicrosoftExcel})
+	epochResults, _, _, err := NewGuesser(WithClock(at), spreadsheets).GuessStrings([]string{"45"})
+	if err != nil {
+		t.Fatalf("Could not guess: %s", err)
+	}
+	ers := epochResults[0]
+	if !ers.Ambiguous || len(ers.TiedWith) != 1 {
+		t.Fatalf("Expected one tie, got %v", ers.TiedWith)
+	}
+	// before the 1900-02-29 that Excel believes in, its dates are a day after the COM date.
+	tie := ers.TiedWith[0]
+	if want := int64(secondsPerDay); tie.SecondsFromMostLikely != want && tie.SecondsFromMostLikely != -want {
+		t.Errorf("Expected the tie %d seconds from the most likely, got %d", want, tie.SecondsFromMostLikely)
+	}
+	if tie.EpochType.Label() == ers.MostLikelyType.Label() || tie.Confidence > ers.Confidence {
+		t.Errorf("The tie should be the other, less confident, result, got %s", tie.EpochType.Label())
+	}
+	epochResults, _, _, _ = NewGuesser(WithClock(at), spreadsheets, WithAmbiguityMargin(0)).GuessStrings(
+		[]string{"45"})
+	if epochResults[0].Ambiguous {
+		t.Errorf("Expected no exact tie, got %v", epochResults[0].TiedWith)
+	}
+	epochResults, _, _, _ = NewGuesser(WithClock(at)).GuessStrings([]string{"1600000000"})
+	if epochResults[0].Ambiguous {
+		t.Errorf("Expected Unix to be clear of the rest, got %v", epochResults[0].TiedWith)
+	}
+	// from serial 61 on, COM and Excel agree on the date, so are not ambiguous.
+	for _, g := range []*Guesser{NewGuesser(WithClock(at), spreadsheets), NewGuesser(WithClock(at))} {
+		epochResults, _, _, _ = g.GuessStrings([]string{"45000"})
+		if epochResults[0].Ambiguous {
+			t.Errorf("Expected 45000 not to be ambiguous, got %v", epochResults[0].TiedWith)
+		}
+	}
+}
+
+var approximateDateTests = []struct {
+	in   string
+	want time.Time
+}{
+	{"2014", time.Date(2014, 7, 2, 12, 0, 0, 0, time.UTC)},
+	{"2014-03", time.Date(2014, 3, 16, 12, 0, 0, 0, time.UTC)},
+	{"March 2014", time.Date(2014, 3, 16, 12, 0, 0, 0, time.UTC)},
+	{"around Mar 2014", time.Date(2014, 3, 16, 12, 0, 0, 0, time.UTC)},
+	{"2014-03-15", time.Date(2014, 3, 15, 12, 0, 0, 0, time.UTC)},
+	{"2014-03-15T08:30:00+01:00", time.Date(2014, 3, 15, 7, 30, 0, 0, time.UTC)},
+}
+
+// Tests whether rough dates are read as the middle of the period they name, and used to rank near.
+func TestNearApproximateDate(t *testing.T) {
+	for _, tt := range approximateDateTests {
+		got, err := ParseApproximateDate(tt.in)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("%q should be %s, got %s %v", tt.in, tt.want, got, err)
+		}
+	}
+	if _, err := ParseApproximateDate("soon"); err == nil {
+		t.Error("Expected an error for a date that is not a date")
+	}
+	at := WithClock(FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)))
+	near, _ := ParseApproximateDate("2001")
+	nearTests := []struct {
+		options []GuesserOption
+		want    string
+	}{
+		{[]GuesserOption{at}, "Unix"},
+		{[]GuesserOption{at, WithNear(near)}, "Unix"},
+		{[]GuesserOption{at, WithNear(near), WithRanker(NearestToNow)}, "Unix"},
+	}
+	for _, tt := range nearTests {
+		epochResults, _, _, _ := NewGuesser(tt.options...).GuessStrings([]string{"1000000000"})
+		if got := epochResults[0].MostLikelyType.EpochName; got != tt.want {
+			t.Errorf("Expected %s, got %s", tt.want, got)
+		}
+		// the epochs still count to the clock's time, not the hint.
+		if got := epochResults[0].MostLikelyType.UTCRightNowInSecondsSince; got != 1792108800 {
+			t.Errorf("Expected the now fields counted to the clock, got %d", got)
+		}
+	}
+}
+
+// Tests whether every date in a rough period is as near as the others, and a date the verdict says is not a timestamp
+// is never ranked first, however near it is.
+func TestNearPeriod(t *testing.T) {
+	start, end, err := ParseApproximatePeriod("2001")
+	if err != nil || !start.Equal(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)) ||
+		!end.Equal(time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected 2001 to be all of 2001, got %s to %s %v", start, end, err)
+	}
+	at := WithClock(FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)))
+	g := NewGuesser(at, WithNearPeriod(start, end), WithRanker(NearestToNow))
+	epochResults, _, _, _ := g.GuessStrings([]string{"1000000000"})
+	confidences := make(map[string]float64)
+	for _, er := range epochResults[0].AllResults {
+		confidences[er.EpochType.Label()] = er.Confidence
+	}
+	// in September and January.
+	if unix, macOSX := confidences["Unix, seconds"], confidences["Mac OS X, milliseconds"]; unix == 0 || unix != macOSX {
+		t.Errorf("Expected dates in 2001 to be as near, got %f for Unix and %f for Mac OS X", unix, macOSX)
+	}
+	// a second after Mac OS X started.
+	g = NewGuesser(at, WithRanker(NearestTo(start)))
+	epochResults, _, _, _ = g.GuessStrings([]string{"1000000000"})
+	ers := epochResults[0]
+	if ers.Verdict == VerdictNotTimestamp || ers.MostLikelyType.Label() == "Mac OS X, nanoseconds" {
+		t.Errorf("Expected a plausible date ranked first, got %s: %s", ers.MostLikelyType.Label(), ers.VerdictReason)
+	}
+	for _, er := range ers.AllResults {
+		if er.EpochType.Label() == "Mac OS X, nanoseconds" && er.Confidence != 0 {
+			t.Errorf("Expected no confidence in Mac OS X nanoseconds, got %f", er.Confidence)
+		}
+	}
+}
+
+// Tests whether a batch of numbers from one system is explained by one epoch, with outliers that do not fit.
+func TestGuessBatch(t *testing.T) {
+	at := FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+	g := NewGuesser(WithClock(at), WithRanker(NearestToNow))
+	var inputs []string
+	for _, day := range []int{10, 20, 21} {
+		n, err := EpochFAT.NumberForDate(time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatalf("Could not count FAT seconds: %s", err)
+		}
+		inputs = append(inputs, fmt.Sprint(n))
+	}
+	// on its own, the first number is nearer now as GPS, five days after FAT.
+	epochResults, _, _, _ := g.GuessStrings(inputs[:1])
+	if epochResults[0].MostLikelyType.EpochName != "GPS" {
+		t.Fatalf("Expected %s to be GPS on its own, got %s", inputs[0], epochResults[0].MostLikelyType.Label())
+	}
+	batch, badStrings, err := g.GuessBatch(append(inputs, "42", "x"))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || len(parseErr.Failures) != 1 {
+		t.Fatalf("Expected a parse error for x, got %v", err)
+	}
+	if batch.EpochType.Label() != "FAT, seconds" {
+		t.Errorf("Expected the batch to be FAT seconds, got %s", batch.EpochType.Label())
+	}
+	if !reflect.DeepEqual(badStrings, []string{"x"}) || len(batch.Values) != 4 {
+		t.Errorf("Expected a value for each number and x to be bad, got %d values and %v", len(batch.Values),
+			badStrings)
+	}
+	if !reflect.DeepEqual(batch.Outliers, []int{3}) || !batch.Values[3].Outlier {
+		t.Errorf("Expected 42 to be the only outlier, got %v", batch.Outliers)
+	}
+	if got := batch.Values[0].DateInEpochUTC; !got.Equal(time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the first number on 2026-10-10 as FAT, got %s", got)
+	}
+	if batch.Confidence <= 0 || batch.Confidence > 1 {
+		t.Errorf("Expected a confidence from 0 to 1, got %f", batch.Confidence)
+	}
+	if _, err := g.GuessBatchInt64s(nil); err == nil {
+		t.Error("Expected an error for an empty batch")
+	}
+}
+
+// Tests whether the unit is found from the gaps between the numbers of a series, and counters are not timestamps.
+var unitFromDeltasTests = []struct {
+	numbers    []int64
+	unit       EpochUnit
+	timestamps bool
+}{
+	{[]int64{1760000000, 1760000003, 1760000004, 1760000010}, UnitSeconds, true},
+	{[]int64{1760000000, 1760000060, 1760000120, 1760000180}, UnitSeconds, true},
+	{[]int64{1760000000000, 1760000001500, 1760000002300, 1760000004600}, UnitMilliseconds, true},
+	{[]int64{1760000000000000, 1760000001200000, 1760000002000000}, UnitMicroseconds, true},
+	{[]int64{1760000000000000000, 1760000000900000000, 1760000002000000000}, UnitNanoseconds, true},
+	{[]int64{1001, 1002, 1003, 1004}, UnitUnspecified, false},
+	{[]int64{5, 5, 5}, UnitUnspecified, false},
+	{[]int64{42}, UnitUnspecified, false},
+	{[]int64{0, 1 << 62}, UnitUnspecified, false},
+	{[]int64{math.MaxInt64, math.MinInt64}, UnitUnspecified, false},
+	{[]int64{math.MinInt64, math.MaxInt64, math.MinInt64}, UnitUnspecified, false},
+}
+
+func TestInferUnitFromDeltas(t *testing.T) {
+	for _, tt := range unitFromDeltasTests {
+		evidence := InferUnitFromDeltas(tt.numbers)
+		if evidence.Unit != tt.unit || evidence.Timestamps != tt.timestamps {
+			t.Errorf("Expected %v to be %s, timestamps %t, got %s, timestamps %t: %s", tt.numbers, tt.unit,
+				tt.timestamps, evidence.Unit, evidence.Timestamps, evidence.Reason)
+		}
+		if evidence.Reason == "" {
+			t.Errorf("Expected a reason for %v", tt.numbers)
+		}
+	}
+	evidence := InferUnitFromDeltas([]int64{1760000000, 1760000600, 1760000300})
+	if evidence.Increasing != 1 || evidence.Decreasing != 1 || evidence.MedianDelta.String() != "300" {
+		t.Errorf("Expected one gap up and one down with a median of 300, got %+v", evidence)
+	}
+	at := FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+	batch, err := NewGuesser(WithClock(at)).GuessBatchInt64s([]int64{1760000000000, 1760000001500, 1760000002300})
+	if err != nil {
+		t.Fatalf("Could not guess the batch: %s", err)
+	}
+	if batch.EpochType.Label() != "Unix, milliseconds" || batch.UnitEvidence.Unit != UnitMilliseconds {
+		t.Errorf("Expected a batch of Unix milliseconds, got %s with evidence of %s", batch.EpochType.Label(),
+			batch.UnitEvidence.Unit)
+	}
+}
+
+// Tests whether the gaps between the numbers of a batch are exact, for numbers too large for an int64 and for decimals.
+var batchGapTests = []struct {
+	in     []string
+	median string
+	unit   EpochUnit
+}{
+	// the batch is nanoseconds, in which a gap of one is too small.
+	{[]string{"18446744073709551615", "18446744073709551614"}, "1", UnitUnspecified},
+	// strace -ttt
+	{[]string{"1600000000.123456", "1600000000.423456", "1600000000.923456", "1600000001.223456"}, "0.300000",
+		UnitSeconds},
+	// a sample every minute, and every hour, which are also plausible gaps in milliseconds.
+	{[]string{"1600000000", "1600000060", "1600000120", "42"}, "60", UnitSeconds},
+	{[]string{"1600000000", "1600003600", "1600007200", "1600010800"}, "3600", UnitSeconds},
+}
+
+func TestGuessBatchGaps(t *testing.T) {
+	g := NewGuesser(WithClock(FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))))
+	for _, tt := range batchGapTests {
+		batch, _, err := g.GuessBatch(tt.in)
+		if err != nil {
+			t.Fatalf("Could not guess the batch %v: %s", tt.in, err)
+		}
+		evidence := batch.UnitEvidence
+		if evidence.MedianDelta.String() != tt.median || evidence.Unit != tt.unit || !evidence.Timestamps {
+			t.Errorf("Expected %v to have a median gap of %s in %s, got %s", tt.in, tt.median, tt.unit,
+				evidence.Reason)
+		}
+		if !strings.Contains(evidence.Reason, "plausible in "+batch.EpochType.Unit.String()) {
+			t.Errorf("Expected the reason for %v to name %s, got %s", tt.in, tt.unit, evidence.Reason)
+		}
+	}
+}
+
+// Tests whether numbers are judged timestamps, unlikely or not timestamps by how far their dates are from now.
+var verdictTests = []struct {
+	in      string
+	verdict Verdict
+}{
+	{"1600000000", VerdictTimestamp},
+	{"42", VerdictNotTimestamp},
+	{"2840000000", VerdictUnlikely},
+	{"9000000000", VerdictNotTimestamp},
+}
+
+func TestVerdict(t *testing.T) {
+	at := FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+	g := NewGuesser(WithClock(at), WithCollection(EpochCollection{EpochUnix}), WithUnits(UnitSeconds))
+	for _, tt := range verdictTests {
+		epochResults, _, _, err := g.GuessStrings([]string{tt.in})
+		if err != nil {
+			t.Fatalf("Could not guess %s: %s", tt.in, err)
+		}
+		if got := epochResults[0].Verdict; got != tt.verdict || epochResults[0].VerdictReason == "" {
+			t.Errorf("Expected %s to be %s, got %s: %s", tt.in, tt.verdict, got, epochResults[0].VerdictReason)
+		}
+	}
+	var verdict Verdict
+	if err := verdict.UnmarshalText([]byte(VerdictUnlikely.String())); err != nil || verdict != VerdictUnlikely {
+		t.Errorf("Expected to read back %s, got %s, %v", VerdictUnlikely, verdict, err)
+	}
+}
+
+// Tests whether phone numbers, which have dates in the future in rare epochs, are not taken for timestamps.
+var phoneNumberTests = []string{"5551234567", "4155550100"}
+
+func TestVerdictPhoneNumbers(t *testing.T) {
+	g := NewGuesser(WithClock(FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))))
+	for _, tt := range phoneNumberTests {
+		epochResults, _, _, err := g.GuessStrings([]string{tt})
+		if err != nil {
+			t.Fatalf("Could not guess %s: %s", tt, err)
+		}
+		if got := epochResults[0].Verdict; got == VerdictTimestamp {
+			t.Errorf("Expected %s not to be a timestamp, got %s: %s", tt, got, epochResults[0].VerdictReason)
+		}
+	}
+}
+
+// Tests whether each number found keeps its text, string, line, column and byte span, and guesses made from them
+// keep the position.
+var positionLines = []string{"started 1600000000", "a\tat 1,600,000,001 ok\nthen ±1600000002", "x 1,2"}
+
+var positionTests = []Position{
+	{Text: "1600000000", Input: 0, Line: 1, Column: 9, Start: 8, End: 18},
+	{Text: "1,600,000,001", Input: 1, Line: 2, Column: 6, Start: 5, End: 18},
+	{Text: "1600000002", Input: 1, Line: 3, Column: 7, Start: 29, End: 39},
+	{Text: "1", Input: 2, Line: 4, Column: 3, Start: 2, End: 3},
+	{Text: "2", Input: 2, Line: 4, Column: 5, Start: 4, End: 5},
+}
+
+func TestExtractNumbersPositions(t *testing.T) {
+	extracted := ExtractNumbers(positionLines, 0)
+	if len(extracted) != len(positionTests) {
+		t.Fatalf("Expected %d numbers, got %+v", len(positionTests), extracted)
+	}
+	for i, number := range extracted {
+		want := positionTests[i]
+		if number.Position != want {
+			t.Errorf("Expected %s at %+v, got %+v", number.Number, want, number.Position)
+		}
+		if got := positionLines[number.Position.Input][number.Position.Start:number.Position.End]; got != want.Text {
+			t.Errorf("Expected the span to hold %s, got %s", want.Text, got)
+		}
+	}
+	g := NewGuesser(WithClock(FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))))
+	epochResults, _, _, err := g.GuessReader(strings.NewReader(strings.Join(positionLines, "\n")))
+	if err != nil || len(epochResults) != len(positionTests) || epochResults[2].Position.Line != 3 {
+		t.Errorf("Expected the third guess from line 3, got %v, %v", epochResults, err)
+	}
+	epochResults, _, _, _ = g.GuessStrings([]string{"42", "  1600000000 "})
+	if p := epochResults[1].Position; p.Input != 1 || p.Start != 2 || p.Column != 3 || p.Text != "1600000000" {
+		t.Errorf("Expected the second string's number at column 3, got %+v", p)
+	}
+}
+
+// Tests whether each input which is not converted has a reason, found through errors.As.
+var parseErrorInputs = []string{"1600000000", "0x7fffffffffffffff", "12ab",
+	"3452543252352353253253252345254325235235325325", " ", "2000000000", "3452543252352353253253252"}
+
+var parseErrorTests = []ParseFailure{
+	{Input: "0x7fffffffffffffff", Reason: ParseOutOfRange},
+	{Input: "12ab", Reason: ParseNotNumeric},
+	{Input: "3452543252352353253253252345254325235235325325", Reason: ParseOverflow},
+	{Input: "", Reason: ParseEmpty},
+	{Input: "3452543252352353253253252", Reason: ParseOutOfRange},
+}
+
+func TestParseError(t *testing.T) {
+	at := WithClock(FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)))
+	epochResults, badStrings, _, err := NewGuesser(at, WithCollection(EpochCollection{EpochUnix}),
+		WithUnits(UnitSeconds)).GuessStrings(parseErrorInputs)
+	if len(epochResults) != 2 || len(badStrings) != len(parseErrorTests) {
+		t.Errorf("Expected 2 results and %d bad strings, got %d and %v", len(parseErrorTests), len(epochResults),
+			badStrings)
+	}
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a *ParseError, got %v", err)
+	}
+	if len(parseErr.Failures) != len(parseErrorTests) {
+		t.Fatalf("Expected %d failures, got %v", len(parseErrorTests), parseErr.Failures)
+	}
+	for i, failure := range parseErr.Failures {
+		if tt := parseErrorTests[i]; failure.Input != tt.Input || failure.Reason != tt.Reason {
+			t.Errorf("Expected %s to fail as %s, got %s as %s", tt.Input, tt.Reason, failure.Input, failure.Reason)
+		}
+	}
+	if !errors.Is(parseErr.Failures[2], strconv.ErrRange) || parseErr.Failures[1].Position.Input != 2 {
+		t.Errorf("Expected the overflow to wrap strconv.ErrRange and 12ab to be the third input, got %+v",
+			parseErr.Failures[1:3])
+	}
+	if _, _, _, err := NewGuesser(at).GuessBigInts([]*big.Int{new(big.Int).Lsh(big.NewInt(1), 70)}); !errors.As(err,
+		&parseErr) || parseErr.Failures[0].Reason != ParseOutOfRange {
+		t.Errorf("Expected a 70 bit number to be out of range, got %v", err)
+	}
+}
+
+// Tests whether inputs keep the text they were written in, and repeats are counted in the order first given.
+var duplicateInputs = []string{"1600000000.50", "0x5f5e1000", "1600000000.50", "+1600000000", "1600000000.50"}
+
+var mergedDuplicateTests = []struct {
+	text        string
+	occurrences int
+}{
+	{"1600000000.50", 3},
+	{"0x5f5e1000", 1},
+	{"+1600000000", 1},
+}
+
+func TestMergedDuplicates(t *testing.T) {
+	at := WithClock(FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)))
+	epochResults, _, _, _ := NewGuesser(at).GuessStrings(duplicateInputs)
+	if len(epochResults) != len(duplicateInputs) || epochResults[0].InputText != "1600000000.50" ||
+		epochResults[0].Occurrences != 1 {
+		t.Errorf("Expected every input guessed once with its text, got %d results", len(epochResults))
+	}
+	epochResults, _, _, _ = NewGuesser(at, WithMergedDuplicates()).GuessStrings(duplicateInputs)
+	if len(epochResults) != len(mergedDuplicateTests) {
+		t.Fatalf("Expected %d merged results, got %d", len(mergedDuplicateTests), len(epochResults))
+	}
+	for i, er := range epochResults {
+		if tt := mergedDuplicateTests[i]; er.InputText != tt.text || er.Occurrences != tt.occurrences {
+			t.Errorf("Expected %s %d times, got %s %d times", tt.text, tt.occurrences, er.InputText, er.Occurrences)
+		}
+	}
+	if epochResults[0].Position.Input != 0 {
+		t.Errorf("Expected the first occurrence's position, got %+v", epochResults[0].Position)
+	}
+}
+
+// Tests whether numbers too large for an int64 are read exactly, and give dates wherever they make sense.
+var bigInputTests = []struct {
+	in       string
+	epoch    EpochType
+	encoding NumberEncoding
+	want     time.Time
+}{
+	// the largest uint64, in nanoseconds since 1970.
+	{"18446744073709551615", EpochUnix, EncodingPlain, time.Date(2554, 7, 21, 23, 34, 33, 709551615, time.UTC)},
+	{"0xffffffffffffffff", EpochUnix, EncodingPlain, time.Date(2554, 7, 21, 23, 34, 33, 709551615, time.UTC)},
+	{"253402300799000000000", EpochUnix, EncodingPlain, time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)},
+	// 1760000000000000200 nanoseconds, read in the wrong byte order as an unsigned 64 bit number.
+	{"14411713235063827480", EpochUnix, EncodingByteSwapped64, time.Unix(1760000000, 200).UTC()},
+}
+
+func TestBigInputs(t *testing.T) {
+	at := WithClock(FixedClock(time.Date(2025, 10, 9, 0, 0, 0, 0, time.UTC)))
+	g := NewGuesser(at, WithCollection(AllEpochs.WithByteSwaps()), WithUnits(UnitNanoseconds))
+	for _, tt := range bigInputTests {
+		epochResults, _, _, err := g.GuessStrings([]string{tt.in})
+		if err != nil {
+			t.Fatalf("Could not guess %s: %s", tt.in, err)
+		}
+		found := false
+		for _, er := range epochResults[0].AllResults {
+			if er.EpochType.EpochName == tt.epoch.EpochName && er.EpochType.Encoding == tt.encoding {
+				found = er.DateInEpochUTC.Equal(tt.want)
+				if !found {
+					t.Errorf("Expected %s to be %s, got %s", tt.in, tt.want, er.DateInEpochUTC)
+				}
+			}
+		}
+		if !found && !t.Failed() {
+			t.Errorf("Expected a result for %s as %s, %s", tt.in, tt.epoch.EpochName, tt.encoding)
+		}
+		if epochResults[0].InputValue.IsInt64() || epochResults[0].InputNumber != math.MaxInt64 {
+			t.Errorf("Expected %s to be too large for an int64, got %d", tt.in, epochResults[0].InputNumber)
+		}
+	}
+	number, err := ParseEpochNumber("18446744073709551615.25")
+	if err != nil || number.String() != "18446744073709551615.25" || number.BigInt().String() != "18446744073709551615" {
+		t.Errorf("Expected a big decimal to be kept exactly, got %s, %v", number, err)
+	}
+	// .NET DateTime.ToBinary of a Local time has the top bit set, so is often written unsigned.
+	epochResults, _, _, err := NewGuesser(at).GuessStrings([]string{"9862272036854775808"})
+	if err != nil {
+		t.Fatalf("Could not guess an unsigned DateTime.ToBinary: %s", err)
+	}
+	found := false
+	for _, er := range epochResults[0].AllResults {
+		if er.EpochType.Encoding == EncodingDateTimeBinary {
+			found = er.DateTimeKind == DateTimeKindLocal && er.DateInEpochUTC.Year() == 2025
+		}
+	}
+	if !found {
+		t.Errorf("Expected an unsigned DateTime.ToBinary to be a Local date in 2025")
+	}
+}
+
+// Tests whether decimals with more digits than an int64 holds keep all of them.
+var bigDecimalTests = []string{"9223372036854775807.5", "-9223372036854775808.25", "1600000000.123456789123456789",
+	"18446744073709551615.25"}
+
+func TestParseBigDecimals(t *testing.T) {
+	for _, tt := range bigDecimalTests {
+		if number, err := ParseEpochNumber(tt); err != nil || number.String() != tt {
+			t.Errorf("Expected %s to be kept exactly, got %s, %v", tt, number, err)
+		}
+	}
+}
+
+// Tests whether small negative numbers are counts just before Unix started, with the default ranker, rather than just
+// before a later epoch like Mac OS X, whose dates are nearer now.
+var smallNegativeTests = []struct {
+	in   string
+	want time.Time
+}{
+	{"-86400", time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)},
+	{"-3600", time.Date(1969, 12, 31, 23, 0, 0, 0, time.UTC)},
+	{"-1", time.Date(1969, 12, 31, 23, 59, 59, 0, time.UTC)},
+}
+
+// Tests whether dates before the start of each epoch are negative numbers, which convert back to the same date and
+// are guessed as that date near it.
+func TestPreEpochDates(t *testing.T) {
+	at := WithClock(FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)))
+	for _, et := range AllEpochs {
+		// whole days, so epochs counted in days land on the date exactly.
+		date := et.EpochDate.AddDate(0, 0, -2)
+		if et.Encoding == EncodingDateTimeBinary {
+			// DateTime.ToBinary only holds dates before 0001-01-01 as Local times wrapped below zero, within a day.
+			date = et.EpochDate.Add(-12 * time.Hour)
+		}
+		number, err := et.NumberForDate(date)
+		if err != nil || number >= 0 {
+			t.Errorf("Expected %s to be a negative number in %s, got %d, %v", date, et.Label(), number, err)
+			continue
+		}
+		back, err := et.DateForNumber(number, true)
+		if err != nil || !back.Equal(date) {
+			t.Errorf("Expected %d in %s to be %s, got %s, %v", number, et.Label(), date, back, err)
+		}
+		epochResults, _, _, err := NewGuesser(at, WithCollection(EpochCollection{et}), WithNear(date)).GuessStrings(
+			[]string{strconv.FormatInt(number, 10)})
+		if err != nil || !epochResults[0].AllResults[0].DateInEpochUTC.Equal(date) {
+			t.Errorf("Expected %d to be guessed as %s in %s, got %v, %v", number, date, et.Label(), epochResults, err)
+		}
+	}
+	epochResults, _, _, err := NewGuesser(at, WithNear(time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC))).GuessStrings(
+		[]string{"-86400"})
+	if err != nil || epochResults[0].MostLikelyType.Label() != EpochUnix.Label() {
+		t.Errorf("Expected -86400 to be Unix seconds near 1969-12-31, got %v, %v", epochResults, err)
+	}
+	for _, tt := range smallNegativeTests {
+		epochResults, _, _, err := NewGuesser(at).GuessStrings([]string{tt.in})
+		if err != nil {
+			t.Fatalf("Could not guess %s: %s", tt.in, err)
+		}
+		ers := epochResults[0]
+		if ers.MostLikelyType.Label() != EpochUnix.Label() || !ers.AllResults[0].DateInEpochUTC.Equal(tt.want) ||
+			ers.Verdict != VerdictNotTimestamp {
+			t.Errorf("Expected %s to be Unix seconds on %s, not a timestamp, got %s %s, %s", tt.in, tt.want,
+				ers.MostLikelyType.Label(), ers.AllResults[0].DateInEpochUTC, ers.VerdictReason)
+		}
+	}
+}
+
+// Tests whether the decimal digits of any script are read as ASCII digits, and found in text where they were written.
+var normalizeDigitsTests = []struct {
+	in, want string
+}{
+	{"１６００００００００", "1600000000"}, // full-width
+	{"١٦٠٠٠٠٠٠٠٠", "1600000000"}, // Arabic-Indic
+	{"۱۶۰۰۰۰۰۰۰۰", "1600000000"}, // Extended Arabic-Indic, as in Persian
+	{"१६००००००००", "1600000000"}, // Devanagari
+	{"𝟏𝟔𝟎𝟎", "1600"},             // mathematical bold, outside the basic multilingual plane
+	{"ts=-１６.５ ok", "ts=-16.5 ok"},
+	{"no digits", "no digits"},
+}
+
+func TestNormalizeDigits(t *testing.T) {
+	// digitValue counts on the Nd table being runs of ten digits, each from zero to nine.
+	for _, r16 := range unicode.Nd.R16 {
+		if r16.Stride != 1 || (r16.Hi-r16.Lo+1)%10 != 0 {
+			t.Errorf("Nd range %U to %U is not runs of ten digits", r16.Lo, r16.Hi)
+		}
+	}
+	for _, r32 := range unicode.Nd.R32 {
+		if r32.Stride != 1 || (r32.Hi-r32.Lo+1)%10 != 0 {
+			t.Errorf("Nd range %U to %U is not runs of ten digits", r32.Lo, r32.Hi)
+		}
+	}
+	for _, tt := range normalizeDigitsTests {
+		if got := NormalizeDigits(tt.in); got != tt.want {
+			t.Errorf("Expected %q to be %q, got %q", tt.in, tt.want, got)
+		}
+	}
+	extracted := ExtractNumbers([]string{"at １,６００,０００,０００ and ٤٢"}, 0)
+	want := []ExtractedNumber{
+		{"1600000000", Position{Text: "１,６００,０００,０００", Line: 1, Column: 4, Start: 3, End: 36}},
+		{"42", Position{Text: "٤٢", Line: 1, Column: 22, Start: 41, End: 45}},
+	}
+	if !reflect.DeepEqual(extracted, want) {
+		t.Errorf("Expected %+v, got %+v", want, extracted)
+	}
+	g := NewGuesser(WithClock(FixedClock(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))))
+	epochResults, _, _, err := g.GuessStrings([]string{"١٦٠٠٠٠٠٠٠٠"})
+	if err != nil || epochResults[0].InputText != "١٦٠٠٠٠٠٠٠٠" || epochResults[0].NormalizedText != "1600000000" ||
+		epochResults[0].InputNumber != 1600000000 {
+		t.Errorf("Expected Arabic-Indic digits to be read as 1600000000, got %+v, %v", epochResults, err)
+	}
+	epochResults, _, _, err = g.GuessStrings([]string{"1600000000"})
+	if err != nil || epochResults[0].NormalizedText != "" {
+		t.Errorf("Expected no normalised text for ASCII digits, got %+v, %v", epochResults, err)
+	}
+}
@@ -0,0 +1,210 @@
+package epochconv
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+)
+
+// Holds the ways an epoch count may be packed into a number.
+
+// NumberEncoding describes how the count of units is stored in an input number. Most epochs store the count as the
+// number itself, but some formats pack other data alongside the count.
+type NumberEncoding int
+
+const (
+	EncodingPlain          NumberEncoding = iota // The number is the count of units
+	EncodingDateTimeBinary                       // .NET DateTime.ToBinary, the top two bits hold a DateTimeKind
+	EncodingExcel1900                            // Excel 1900 date system serial days, which count 1900-02-29
+	EncodingByteSwapped32                        // An unsigned 32 bit count read in the wrong byte order
+	EncodingByteSwapped64                        // A signed 64 bit count read in the wrong byte order
+)
+
+var encodingNames = map[NumberEncoding]string{
+	EncodingPlain:          "plain",
+	EncodingDateTimeBinary: "DateTime.ToBinary",
+	EncodingExcel1900:      "Excel 1900 date system",
+	EncodingByteSwapped32:  "byte-swapped, little-endian 32-bit",
+	EncodingByteSwapped64:  "byte-swapped, little-endian 64-bit",
+}
+
+// ErrWrongEncoding is returned, wrapped with the details, when a number cannot be unpacked by an epoch's Encoding,
+// such as a decimal given to a byte-swapped epoch. Check for it with errors.Is.
+var ErrWrongEncoding = errors.New("Number cannot be unpacked by the encoding")
+
+// WithByteSwaps is a method on an EpochCollection. It returns the collection along with a copy of each plain epoch
+// that reads numbers byte-swapped, as 32 and 64 bit integers. Numbers copied out of memory dumps and hex editors are
+// often read in the wrong byte order; a number read big-endian from little-endian memory converts correctly in the
+// copies. The copies are ranked alongside the originals, and labelled by their Encoding.
+func (ec EpochCollection) WithByteSwaps() (ecOut EpochCollection) {
+	ecOut = append(ecOut, ec...)
+	for _, swap := range []NumberEncoding{EncodingByteSwapped32, EncodingByteSwapped64} {
+		for _, et := range ec {
+			if et.Encoding != EncodingPlain {
+				continue
+			}
+			et.Encoding = swap
+			ecOut = append(ecOut, et)
+		}
+	}
+	return ecOut
+}
+
+// String satisfies the Stringer interface, so this is printed when %s is used in a formatting string for this type.
+func (ne NumberEncoding) String() string {
+	if name, ok := encodingNames[ne]; ok {
+		return name
+	}
+	return fmt.Sprintf("NumberEncoding(%d)", int(ne))
+}
+
+// MarshalText satisfies encoding.TextMarshaler, so encodings are readable in JSON output.
+func (ne NumberEncoding) MarshalText() ([]byte, error) {
+	if _, ok := encodingNames[ne]; !ok {
+		return nil, fmt.Errorf("Unknown number encoding %d", int(ne))
+	}
+	return []byte(ne.String()), nil
+}
+
+// UnmarshalText satisfies encoding.TextUnmarshaler, the reverse of MarshalText.
+func (ne *NumberEncoding) UnmarshalText(text []byte) error {
+	for encoding, name := range encodingNames {
+		if name == string(text) {
+			*ne = encoding
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown number encoding %q", text)
+}
+
+// applies reports whether a number could have been produced by the encoding. Numbers that decode to the same count
+// as the plain encoding are not considered, since the plain interpretation already covers them.
+func (ne NumberEncoding) applies(number EpochNumber) bool {
+	decoded, err := ne.decode(number)
+	if err != nil {
+		return false
+	}
+	switch ne {
+	case EncodingDateTimeBinary:
+		bits, _ := number.bits64()
+		_, kind := DecodeDateTimeBinary(int64(bits))
+		return kind != DateTimeKindUnspecified
+	case EncodingByteSwapped32, EncodingByteSwapped64:
+		return decoded.Cmp(number) != 0
+	default:
+		return true
+	}
+}
+
+// decode returns the count of units held in the number, or an error wrapping ErrWrongEncoding if the number could
+// not hold one.
+func (ne NumberEncoding) decode(number EpochNumber) (EpochNumber, error) {
+	switch ne {
+	case EncodingDateTimeBinary:
+		// the top bit holds part of the kind, so values with it set may be given as unsigned.
+		bits, ok := number.bits64()
+		if !ok {
+			return number, fmt.Errorf("%s is not a 64 bit whole number for %s: %w", number, ne, ErrWrongEncoding)
+		}
+		ticks, _ := DecodeDateTimeBinary(int64(bits))
+		return NewEpochNumber(ticks), nil
+	case EncodingExcel1900:
+		// Excel copied Lotus 1-2-3 in treating 1900 as a leap year, so serial 60 is 1900-02-29, a day that never
+		// happened. Serials from 60 on are a day ahead of the calendar; 60 itself reads as 1900-02-28. Serials too
+		// large for an int64 are thousands of years past the last supported date, so are left as they are.
+		if !number.IsInt64() {
+			return number, nil
+		}
+		if number.Int64() >= excelPhantomLeapDay {
+			return number.addWhole(-1), nil
+		}
+		return number, nil
+	case EncodingByteSwapped32:
+		if !number.IsInt64() || !number.IsInteger() || number.Mantissa < 0 || number.Mantissa > math.MaxUint32 {
+			return number, fmt.Errorf("%s is not a 32 bit unsigned number for %s: %w", number, ne, ErrWrongEncoding)
+		}
+		return NewEpochNumber(int64(bits.ReverseBytes32(uint32(number.Mantissa)))), nil
+	case EncodingByteSwapped64:
+		// a signed number is read back signed, and an unsigned number too large for an int64 unsigned.
+		value, ok := number.bits64()
+		if !ok {
+			return number, fmt.Errorf("%s is not a 64 bit whole number for %s: %w", number, ne, ErrWrongEncoding)
+		}
+		if number.IsInt64() {
+			return NewEpochNumber(int64(bits.ReverseBytes64(value))), nil
+		}
+		return NewBigEpochNumber(new(big.Int).SetUint64(bits.ReverseBytes64(value))), nil
+	default:
+		return number, nil
+	}
+}
+
+// excelPhantomLeapDay is the serial Excel gives to 1900-02-29.
+const excelPhantomLeapDay = 60
+
+// DateTimeKind is the .NET System.DateTimeKind stored alongside the ticks by DateTime.ToBinary.
+type DateTimeKind int
+
+const (
+	DateTimeKindUnspecified DateTimeKind = iota
+	DateTimeKindUtc
+	DateTimeKindLocal
+)
+
+var dateTimeKindNames = map[DateTimeKind]string{
+	DateTimeKindUnspecified: "Unspecified",
+	DateTimeKindUtc:         "Utc",
+	DateTimeKindLocal:       "Local",
+}
+
+// String satisfies the Stringer interface, so this is printed when %s is used in a formatting string for this type.
+func (k DateTimeKind) String() string {
+	if name, ok := dateTimeKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("DateTimeKind(%d)", int(k))
+}
+
+// MarshalText satisfies encoding.TextMarshaler, so kinds are readable in JSON output.
+func (k DateTimeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText satisfies encoding.TextUnmarshaler, the reverse of MarshalText.
+func (k *DateTimeKind) UnmarshalText(text []byte) error {
+	for kind, name := range dateTimeKindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown DateTimeKind %q", text)
+}
+
+// Masks and limits used by .NET to pack a DateTime into 64 bits.
+const (
+	dateTimeTicksMask    = 0x3FFFFFFFFFFFFFFF
+	dateTimeKindShift    = 62
+	dateTimeTicksCeiling = 0x4000000000000000
+	dateTimeTicksPerDay  = 864000000000
+)
+
+// DecodeDateTimeBinary splits a value produced by .NET DateTime.ToBinary into ticks since 0001-01-01 and the
+// DateTimeKind held in the top two bits. Local values are stored by .NET as UTC ticks, which may have wrapped below
+// zero, so the returned ticks are always UTC.
+func DecodeDateTimeBinary(binary int64) (ticks int64, kind DateTimeKind) {
+	ticks = binary & dateTimeTicksMask
+	switch uint64(binary) >> dateTimeKindShift {
+	case 0:
+		return ticks, DateTimeKindUnspecified
+	case 1:
+		return ticks, DateTimeKindUtc
+	default:
+		if ticks > dateTimeTicksCeiling-dateTimeTicksPerDay {
+			ticks -= dateTimeTicksCeiling
+		}
+		return ticks, DateTimeKindLocal
+	}
+}
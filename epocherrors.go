@@ -0,0 +1,121 @@
+package epochconv
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Holds the errors for inputs which could not be converted.
+
+// ParseReason is why an input could not be converted.
+type ParseReason int
+
+const (
+	ParseNotNumeric ParseReason = iota // The input is not a number in the base it was read in
+	ParseOverflow                      // The number is wider than 128 bits
+	ParseEmpty                         // The input is empty, or only whitespace
+	ParseOutOfRange                    // The number has no date in any epoch, as it is outside the years supported
+)
+
+// parseReasonNames holds the names used for printing, and for marshalling to and from JSON.
+var parseReasonNames = map[ParseReason]string{
+	ParseNotNumeric: "not a number",
+	ParseOverflow:   "wider than 128 bits",
+	ParseEmpty:      "empty",
+	ParseOutOfRange: "out of the supported range",
+}
+
+// String satisfies the Stringer interface, so this is printed when %s is used in a formatting string for this type.
+func (r ParseReason) String() string {
+	if name, ok := parseReasonNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("ParseReason(%d)", int(r))
+}
+
+// MarshalText satisfies encoding.TextMarshaler, so reasons are readable in JSON output.
+func (r ParseReason) MarshalText() ([]byte, error) {
+	if _, ok := parseReasonNames[r]; !ok {
+		return nil, fmt.Errorf("Unknown parse reason %d", int(r))
+	}
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText satisfies encoding.TextUnmarshaler, the reverse of MarshalText.
+func (r *ParseReason) UnmarshalText(text []byte) error {
+	for reason, name := range parseReasonNames {
+		if name == string(text) {
+			*r = reason
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown parse reason %q", string(text))
+}
+
+// ParseFailure is one input which could not be converted, and why.
+type ParseFailure struct {
+	Input    string      `json:"input"`
+	Reason   ParseReason `json:"reason"`
+	Position Position    `json:"position"` // Where the input was found, empty for inputs not read from text
+	Err      error       `json:"-"`        // The error from reading the number, if there was one
+}
+
+// Error satisfies the error interface, naming the input and the reason.
+func (f ParseFailure) Error() string {
+	return fmt.Sprintf("%s (%s)", f.Input, f.Reason)
+}
+
+// Unwrap returns the error from reading the number, so errors.Is can find strconv.ErrRange.
+func (f ParseFailure) Unwrap() error {
+	return f.Err
+}
+
+// ParseError is returned when some inputs could not be converted, with a ParseFailure for each. The inputs which
+// could be converted are still returned. Use errors.As to get at it from the error a guess returns.
+type ParseError struct {
+	Failures []ParseFailure
+}
+
+// Error satisfies the error interface, listing each input with the reason it failed.
+func (e *ParseError) Error() string {
+	failures := make([]string, len(e.Failures))
+	for i, failure := range e.Failures {
+		failures[i] = failure.Error()
+	}
+	return fmt.Sprintf("Some strings not converted, %s", strings.Join(failures, ", "))
+}
+
+// Inputs lists the inputs which failed, in the order they were given.
+func (e *ParseError) Inputs() (inputs []string) {
+	for _, failure := range e.Failures {
+		inputs = append(inputs, failure.Input)
+	}
+	return inputs
+}
+
+// sortFailures puts the failures in the order their inputs were given, by where each was found, since those which
+// could not be read are found before those out of range.
+func sortFailures(failures []ParseFailure) {
+	sort.SliceStable(failures, func(i, j int) bool {
+		a, b := failures[i].Position, failures[j].Position
+		if a.Input != b.Input {
+			return a.Input < b.Input
+		}
+		return a.Start < b.Start
+	})
+}
+
+// parseFailure classifies the error from ParseEpochNumberInBase for the input.
+func parseFailure(input string, position Position, err error) ParseFailure {
+	failure := ParseFailure{Input: input, Reason: ParseNotNumeric, Position: position, Err: err}
+	switch {
+	case strings.TrimSpace(input) == "":
+		failure.Reason = ParseEmpty
+	case errors.Is(err, strconv.ErrRange):
+		failure.Reason = ParseOverflow
+	}
+	return failure
+}
@@ -0,0 +1,268 @@
+package epochconv
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Holds the extraction of numbers from text, such as log lines and the clipboard.
+
+// Patterns for numbers in strings, by the base given to NumbersInStringsInBase. Base 10 numbers may have a sign, a
+// decimal part, and digit groups split by a separator, which groupedNumbers takes apart again. Numbers in other bases
+// must be whole words, so the letters of words such as "file" are not read as hex, and bare hex must have a digit in
+// it, so words such as "bad" and "face" are not either.
+var numberPatterns = map[int]*regexp.Regexp{
+	0:  regexp.MustCompile(`0[xX][0-9a-fA-F]+|0[oO][0-7]+|0[bB][01]+|` + decimalNumber),
+	2:  regexp.MustCompile(`\b(0[bB])?[01]+\b`),
+	8:  regexp.MustCompile(`\b(0[oO])?[0-7]+\b`),
+	10: regexp.MustCompile(decimalNumber),
+	16: regexp.MustCompile(`\b(0[xX][0-9a-fA-F]+|[0-9a-fA-F]*[0-9][0-9a-fA-F]*)\b`),
+}
+
+// decimalNumber is a base 10 number, with an optional sign, digit groups and decimal part. The sign may be the minus
+// sign U+2212 used in typeset text, as well as a hyphen.
+const decimalNumber = `[-+\x{2212}]?[0-9]+(` + groupSeparators + `[0-9]+)*(\.[0-9]+)?`
+
+// minusSign is U+2212, which numbers are given with as a plain hyphen instead.
+const minusSign = "\u2212"
+
+// groupSeparators are those written between groups of three digits: commas, underscores as in Go, and thin, narrow
+// no-break and no-break spaces as in SI and French.
+const groupSeparators = `(?:,|_|\x{2009}|\x{202F}|\x{00A0})`
+
+var groupSeparator = regexp.MustCompile(groupSeparators)
+
+// Patterns for text which has digits in it but is not a number, and is skipped when looking for numbers: UUIDs, ISO
+// dates with an optional time, dates with slashes such as 2021/03/04 or 4/3/2021, times of day such as 12:30:45 or
+// 09:05, dotted quads and versions, such as 192.168.0.1, 1.2.3-beta or v1.2, and numbers in scientific notation with
+// a decimal part or a signed exponent, such as 1.5e9 or 1E+09. Without either, as in 1e9, they are words of hex
+// digits.
+var notNumberPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`[0-9a-fA-F]{8}(-[0-9a-fA-F]{4}){3}-[0-9a-fA-F]{12}`),
+	regexp.MustCompile(`[0-9]{4}-[0-9]{2}-[0-9]{2}` +
+		`([T ][0-9]{2}:[0-9]{2}(:[0-9]{2}([.,][0-9]+)?)?(Z|[-+][0-9]{2}:?[0-9]{2})?)?`),
+	regexp.MustCompile(`\b[0-9]{1,4}/[0-9]{1,2}/[0-9]{1,4}\b`),
+	regexp.MustCompile(`\b[0-9]{1,2}:[0-9]{2}(:[0-9]{2}([.,][0-9]+)?)?\b`),
+	regexp.MustCompile(`[vV]?[0-9]+(\.[0-9]+){2,}([-+][0-9A-Za-z.-]+)?|[vV][0-9]+\.[0-9]+`),
+	regexp.MustCompile(`[0-9]+(\.[0-9]+[eE][-+]?|[eE][-+])[0-9]+`),
+}
+
+// hexWord matches words of hex digits with both digits and letters in them, like a hash or 5f5e1000, which are
+// skipped unless the base is 16.
+var hexWord = regexp.MustCompile(`\b[0-9a-fA-F]*([0-9][a-fA-F]|[a-fA-F][0-9])[0-9a-fA-F]*\b`)
+
+// prefixedNumber matches numbers with a base prefix, such as 0b101, which hexWord would otherwise skip.
+var prefixedNumber = regexp.MustCompile(`^(0[xX][0-9a-fA-F]+|0[oO][0-7]+|0[bB][01]+)$`)
+
+// Position is where a number was found in its input, so tools can point at it or rewrite it in place.
+type Position struct {
+	Source string `json:"source,omitempty"` // Name of the input, such as a file name, when the caller gives one
+	Text   string `json:"text"`             // The number as written, such as 1,600,000,000
+	Input  int    `json:"input"`            // Index of the string the number is in, from 0
+	Line   int    `json:"line"`             // Line the number is on, from 1, where each string starts a new line
+	Column int    `json:"column"`           // Character on the line the number starts at, from 1
+	Start  int    `json:"start"`            // Byte offset of the start of Text in its string
+	End    int    `json:"end"`              // Byte offset just after the end of Text in its string
+}
+
+// ExtractedNumber is a number found in text by ExtractNumbers, with where it was found.
+type ExtractedNumber struct {
+	Number   string   `json:"number"` // The number ready to parse, with any digit group separators removed
+	Position Position `json:"position"`
+}
+
+// Given a slice of strings which could have integer data, create a new slice of only numbers in any of the strings.
+// A sign and a decimal part are kept with their number, and hex, octal and binary numbers with a 0x, 0o or 0b prefix
+// are kept whole. A minus sign − is given as a hyphen, and digit groups are joined, so 1,600,000,000 is 1600000000,
+// while digits that are part of something else - UUIDs, dates, times of day, IP addresses, versions, scientific
+// notation and words of hex digits - are skipped.
+func NumbersInStrings(stringsToClean []string) (numbersOnly []string) {
+	return NumbersInStringsInBase(stringsToClean, 0)
+}
+
+// NumbersInStringsInBase is NumbersInStrings for numbers written in a base, see ParseEpochNumberInBase. With a base
+// of 16, words of hex digits are taken as numbers whether they have a 0x prefix or not, as long as they have a digit.
+func NumbersInStringsInBase(stringsToClean []string, base int) (numbersOnly []string) {
+	numbersOnly = make([]string, 0)
+	for _, extracted := range ExtractNumbers(stringsToClean, base) {
+		numbersOnly = append(numbersOnly, extracted.Number)
+	}
+	return numbersOnly
+}
+
+// ExtractNumbers is NumbersInStringsInBase, keeping where each number was found. Each string starts a new line, so
+// lines read one at a time from a file keep their line numbers. Digits from any script, such as full-width １ or
+// Arabic-Indic ١, are found too; the Number has them as ASCII digits, while the Position is of the text as written.
+func ExtractNumbers(stringsToClean []string, base int) (extracted []ExtractedNumber) {
+	re, ok := numberPatterns[base]
+	if !ok {
+		re = numberPatterns[0]
+	}
+	line := 1
+	for i, s := range stringsToClean {
+		normalized, offsets := normalizeDigits(s)
+		for _, loc := range re.FindAllStringIndex(blankNotNumbers(normalized, base), -1) {
+			start, end := loc[0], loc[1]
+			if sign := signLength(normalized[start:]); sign > 0 && start > 0 && isWordByte(normalized[start-1]) {
+				// a dash inside a word, as in a-5, is not a sign.
+				start += sign
+			}
+			for _, span := range groupedNumbers(normalized[start:end]) {
+				number := strings.Replace(span.number, minusSign, "-", 1)
+				extracted = append(extracted, ExtractedNumber{Number: number, Position: positionIn(s, i, line,
+					offsets.original(start+span.start), offsets.original(start+span.end))})
+			}
+		}
+		line += strings.Count(s, "\n") + 1
+	}
+	return extracted
+}
+
+// NormalizeDigits replaces the decimal digits of any script, those in the Unicode category Nd such as full-width ０
+// to ９ or Arabic-Indic ٠ to ٩, with the ASCII digits of the same value. Everything else is left as it is.
+func NormalizeDigits(s string) string {
+	normalized, _ := normalizeDigits(s)
+	return normalized
+}
+
+// digitOffsets maps each byte offset in a string with its digits normalised back to the offset in the string as
+// written. It is nil when no digits were replaced, and the offsets are the same.
+type digitOffsets []int
+
+// original is the offset in the string as written of the normalised offset.
+func (o digitOffsets) original(offset int) int {
+	if o == nil {
+		return offset
+	}
+	return o[offset]
+}
+
+// normalizeDigits is NormalizeDigits, also returning where each byte of the result came from.
+func normalizeDigits(s string) (string, digitOffsets) {
+	first := strings.IndexFunc(s, isOtherDigit)
+	if first < 0 {
+		return s, nil
+	}
+	var b strings.Builder
+	offsets := make(digitOffsets, 0, len(s)+1)
+	for i := 0; i < first; i++ {
+		offsets = append(offsets, i)
+	}
+	b.WriteString(s[:first])
+	for i, r := range s[first:] {
+		i += first
+		if isOtherDigit(r) {
+			b.WriteByte(byte('0' + digitValue(r)))
+			offsets = append(offsets, i)
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		b.WriteString(s[i : i+size])
+		for j := 0; j < size; j++ {
+			offsets = append(offsets, i+j)
+		}
+	}
+	return b.String(), append(offsets, len(s))
+}
+
+// isOtherDigit reports whether r is a decimal digit other than an ASCII one.
+func isOtherDigit(r rune) bool {
+	return r > unicode.MaxASCII && unicode.Is(unicode.Nd, r)
+}
+
+// digitValue is the value of a decimal digit from any script. The digits of each script are runs of ten in the Nd
+// table, from zero to nine, so the value is how far the digit is into its run.
+func digitValue(r rune) int {
+	for _, r16 := range unicode.Nd.R16 {
+		if r >= rune(r16.Lo) && r <= rune(r16.Hi) {
+			return int(r-rune(r16.Lo)) % 10
+		}
+	}
+	for _, r32 := range unicode.Nd.R32 {
+		if r >= rune(r32.Lo) && r <= rune(r32.Hi) {
+			return int(r-rune(r32.Lo)) % 10
+		}
+	}
+	return 0
+}
+
+// positionIn is the position of s[start:end], where s is the input'th string and starts on line.
+func positionIn(s string, input, line, start, end int) Position {
+	lineStart := strings.LastIndexByte(s[:start], '\n') + 1
+	return Position{Text: s[start:end], Input: input, Line: line + strings.Count(s[:start], "\n"),
+		Column: utf8.RuneCountInString(s[lineStart:start]) + 1, Start: start, End: end}
+}
+
+// blankNotNumbers replaces text which has digits in it but is not a number with spaces, keeping the length of the
+// string the same.
+func blankNotNumbers(s string, base int) string {
+	patterns := notNumberPatterns
+	if base != 16 {
+		patterns = append(patterns[:len(patterns):len(patterns)], hexWord)
+	}
+	for _, re := range patterns {
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			if prefixedNumber.MatchString(match) {
+				return match
+			}
+			return strings.Repeat(" ", len(match))
+		})
+	}
+	return s
+}
+
+// numberSpan is a number found in a run of digits, with its byte offsets in the run.
+type numberSpan struct {
+	number     string
+	start, end int
+}
+
+// groupedNumbers joins the digit groups of a number, so 1,600,000,000 is 1600000000. When the groups are not all
+// three digits, with the same separator, the separators are between numbers instead, as in 1,2,3.
+func groupedNumbers(number string) []numberSpan {
+	separators := groupSeparator.FindAllStringIndex(number, -1)
+	if len(separators) == 0 {
+		return []numberSpan{{number, 0, len(number)}}
+	}
+	fraction := ""
+	if i := strings.IndexByte(number, '.'); i >= 0 {
+		fraction = number[i:]
+	}
+	var spans []numberSpan
+	grouped := true
+	start := 0
+	for i, separator := range separators {
+		group := number[start:separator[0]]
+		if i == 0 {
+			grouped = len(group)-signLength(group) <= 3
+		} else {
+			grouped = grouped && len(group) == 3
+		}
+		grouped = grouped && number[separator[0]:separator[1]] == number[separators[0][0]:separators[0][1]]
+		spans = append(spans, numberSpan{group, start, separator[0]})
+		start = separator[1]
+	}
+	last := numberSpan{number[start:], start, len(number)}
+	spans = append(spans, last)
+	if grouped && len(strings.TrimSuffix(last.number, fraction)) == 3 {
+		return []numberSpan{{groupSeparator.ReplaceAllString(number, ""), 0, len(number)}}
+	}
+	return spans
+}
+
+// signLength is the length in bytes of the sign s starts with, or 0 when it has none.
+func signLength(s string) int {
+	switch {
+	case strings.HasPrefix(s, "-"), strings.HasPrefix(s, "+"):
+		return 1
+	case strings.HasPrefix(s, minusSign):
+		return len(minusSign)
+	}
+	return 0
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
@@ -0,0 +1,192 @@
+package epochconv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+)
+
+// Holds the Guesser, which makes guesses with a configuration set up once.
+
+// Guesser guesses the epochs of numbers, like GuessesForStrings, with a configuration chosen by GuesserOptions when
+// it is made. A Guesser is never changed after NewGuesser returns it, so one may be shared by many goroutines.
+type Guesser struct {
+	config guessConfig
+}
+
+// GuesserOption sets part of the configuration of a Guesser, see NewGuesser.
+type GuesserOption func(*guessConfig)
+
+// NewGuesser returns a Guesser configured by the options. Without options it guesses from AllEpochs, counted in
+// DefaultUnits, detecting the base of each number, ranked PriorWeighted against the SystemClock, with local dates in
+// time.Local, and ties found within the DefaultAmbiguityMargin.
+func NewGuesser(options ...GuesserOption) *Guesser {
+	config := guessConfig{collection: AllEpochs, ambiguityMargin: DefaultAmbiguityMargin}
+	for _, option := range options {
+		option(&config)
+	}
+	return &Guesser{config: config}
+}
+
+// WithCollection guesses from the epochs in the collection, rather than AllEpochs.
+func WithCollection(ec EpochCollection) GuesserOption {
+	ec = append(EpochCollection(nil), ec...)
+	return func(c *guessConfig) {
+		c.collection = ec
+	}
+}
+
+// WithClock ranks numbers against the clock's time, rather than the SystemClock.
+func WithClock(clock Clock) GuesserOption {
+	return func(c *guessConfig) {
+		c.clock = clock
+	}
+}
+
+// WithNear ranks numbers by how close they are to the hint, a rough date they are thought to have been written
+// around, rather than the clock's time. See ParseApproximateDate. A zero hint ranks against the clock's time.
+func WithNear(hint time.Time) GuesserOption {
+	return WithNearPeriod(hint, hint)
+}
+
+// WithNearPeriod ranks numbers by how close they are to the period from start to end, such as the year they are
+// thought to have been written in, rather than the clock's time. Every date in the period is as near as can be, so
+// a number is not ranked by whether it is early or late in it. See ParseApproximatePeriod. A zero start ranks against
+// the clock's time.
+func WithNearPeriod(start, end time.Time) GuesserOption {
+	return func(c *guessConfig) {
+		c.hint = period{start: start, end: end}
+	}
+}
+
+// WithLocation gives the local dates, DateInEpochLocal, in the location rather than time.Local.
+func WithLocation(loc *time.Location) GuesserOption {
+	return func(c *guessConfig) {
+		c.location = loc
+	}
+}
+
+// WithZones also gives each date in each of the zones, in DatesInZones.
+func WithZones(zones ...*time.Location) GuesserOption {
+	zones = append([]*time.Location(nil), zones...)
+	return func(c *guessConfig) {
+		c.zones = zones
+	}
+}
+
+// WithBase reads strings in the base, see ParseEpochNumberInBase. A base of 0, the default, detects the base.
+func WithBase(base int) GuesserOption {
+	return func(c *guessConfig) {
+		c.base = base
+	}
+}
+
+// WithUnits allows only numbers counted in the units, rather than DefaultUnits. Epochs bound to other units, such as
+// spreadsheet days, are left out unless their unit is given.
+func WithUnits(units ...EpochUnit) GuesserOption {
+	units = append([]EpochUnit(nil), units...)
+	return func(c *guessConfig) {
+		c.units = units
+	}
+}
+
+// WithWindow allows only dates from after up to before. A zero time leaves that end of the window open. Numbers with
+// no date in the window have no plausible match, and are not guessed.
+func WithWindow(after, before time.Time) GuesserOption {
+	return func(c *guessConfig) {
+		c.after, c.before = after, before
+	}
+}
+
+// WithRanker ranks the candidates for each number with the ranker, rather than PriorWeighted.
+func WithRanker(ranker Ranker) GuesserOption {
+	return func(c *guessConfig) {
+		c.ranker = ranker
+	}
+}
+
+// WithMinConfidence leaves out results less confident than minConfidence, from 0 to 1. Numbers with no result that
+// confident have no plausible match, and are not guessed.
+func WithMinConfidence(minConfidence float64) GuesserOption {
+	return func(c *guessConfig) {
+		c.minConfidence = minConfidence
+	}
+}
+
+// WithMergedDuplicates guesses each input once, however many times it is given, with the count in Occurrences. Inputs
+// are the same when they are written the same way, so 0x10 and 16 are guessed apart, and results keep the order
+// inputs were first given in.
+func WithMergedDuplicates() GuesserOption {
+	return func(c *guessConfig) {
+		c.mergeDuplicates = true
+	}
+}
+
+// DefaultAmbiguityMargin is the ambiguity margin used when none is given, see WithAmbiguityMargin.
+const DefaultAmbiguityMargin = 0.25
+
+// WithAmbiguityMargin marks results Ambiguous when other results are within the margin of the most likely, as a
+// fraction of its confidence. With a margin of 0.25, a result three quarters as confident as the most likely is tied
+// with it. A margin of 0 finds only exact ties.
+func WithAmbiguityMargin(margin float64) GuesserOption {
+	return func(c *guessConfig) {
+		c.ambiguityMargin = margin
+	}
+}
+
+// GuessStrings is a method on a Guesser. It is GuessesForStrings with the Guesser's configuration. Numbers which
+// have a date in some epoch, but none that is plausible - outside the window, ruled out by the Ranker or less
+// confident than the minimum - are returned in noMatch rather than badStrings. The error lists both, and holds a
+// *ParseError, found with errors.As, giving the reason each of the badStrings failed.
+func (g *Guesser) GuessStrings(stringsToConvert []string) (epochResults []EpochResults, badStrings []string,
+	noMatch []string, err error) {
+	inputs, failures := stringSliceToEpochNumbers(stringsToConvert, g.config.base)
+	return g.config.guessInputs(inputs, failures)
+}
+
+// GuessInt64s is a method on a Guesser. It is GuessStrings for numbers which have already been read. Numbers with no
+// date in any epoch are returned in badStrings.
+func (g *Guesser) GuessInt64s(numbers []int64) (epochResults []EpochResults, badStrings []string, noMatch []string,
+	err error) {
+	inputs := make([]parsedInput, len(numbers))
+	for i, number := range numbers {
+		inputs[i] = parsedInput{value: NewEpochNumber(number), base: 10}
+	}
+	return g.config.guessInputs(inputs, nil)
+}
+
+// GuessBigInts is a method on a Guesser. It is GuessInt64s for numbers of any size, such as unsigned 64 bit counters.
+// Numbers with no date in any epoch are returned in badStrings.
+func (g *Guesser) GuessBigInts(numbers []*big.Int) (epochResults []EpochResults, badStrings []string,
+	noMatch []string, err error) {
+	inputs := make([]parsedInput, len(numbers))
+	for i, number := range numbers {
+		inputs[i] = parsedInput{value: NewBigEpochNumber(number), base: 10}
+	}
+	return g.config.guessInputs(inputs, nil)
+}
+
+// GuessExtracted is a method on a Guesser. It is GuessStrings for numbers found by ExtractNumbers, and each result
+// keeps the Position its number was found at.
+func (g *Guesser) GuessExtracted(numbers []ExtractedNumber) (epochResults []EpochResults, badStrings []string,
+	noMatch []string, err error) {
+	inputs, failures := extractedToEpochNumbers(numbers, g.config.base)
+	return g.config.guessInputs(inputs, failures)
+}
+
+// GuessReader is a method on a Guesser. It reads text until the end, such as a log file, and guesses every number in
+// it, as found by ExtractNumbers in the Guesser's base, with the line and column each was found at.
+func (g *Guesser) GuessReader(r io.Reader) (epochResults []EpochResults, badStrings []string, noMatch []string,
+	err error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, nil, nil, fmt.Errorf("Could not read numbers: %w", err)
+	}
+	return g.GuessExtracted(ExtractNumbers(lines, g.config.base))
+}
@@ -0,0 +1,306 @@
+package epochconv
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Holds the exact decimal numbers parsed from input.
+
+// EpochNumber is a number read from input, kept exactly as a decimal rather than a float. Its value is
+// Mantissa / 10^Scale, so 44197.75 is held as a Mantissa of 4419775 and a Scale of 2. Whole numbers have a Scale of 0.
+// Numbers too large for an int64 Mantissa, such as unsigned 64 bit counters, are held as a big integer instead, see
+// NewBigEpochNumber and BigMantissa.
+type EpochNumber struct {
+	Mantissa int64    // All digits of the number with the decimal point removed, and the sign.
+	Scale    int      // Count of digits after the decimal point.
+	big      *big.Int // The mantissa when it does not fit in Mantissa, which is then 0. Never changed once set.
+}
+
+// maxBits is the widest number read, wide enough for 128 bit fields such as UUIDs. Wider numbers are rejected as
+// out of range with strconv.ErrRange, rather than held to be converted to dates they could never give.
+const maxBits = 128
+
+// maxScale is the most digits after the decimal point that are kept, since 10^18 is the largest power of ten in an
+// int64. Digits past this are below a nanosecond in every unit, even days, and are dropped.
+const maxScale = 18
+
+// NewEpochNumber returns the EpochNumber for a whole number.
+func NewEpochNumber(number int64) EpochNumber {
+	return EpochNumber{Mantissa: number}
+}
+
+// NewBigEpochNumber returns the EpochNumber for a whole number of any size. The number is copied.
+func NewBigEpochNumber(number *big.Int) EpochNumber {
+	return newBigEpochNumber(number, 0)
+}
+
+// newBigEpochNumber returns the EpochNumber mantissa/10^scale, held in Mantissa when it fits.
+func newBigEpochNumber(mantissa *big.Int, scale int) EpochNumber {
+	if mantissa.IsInt64() {
+		return EpochNumber{Mantissa: mantissa.Int64(), Scale: scale}
+	}
+	return EpochNumber{Scale: scale, big: new(big.Int).Set(mantissa)}
+}
+
+// parseBig reads digits in a base as a big integer, for numbers too large for an int64.
+func parseBig(s string, digits string, base int) (*big.Int, error) {
+	mantissa, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return nil, &strconv.NumError{Func: "ParseEpochNumber", Num: s, Err: strconv.ErrSyntax}
+	}
+	if mantissa.BitLen() > maxBits {
+		return nil, fmt.Errorf("%q is wider than %d bits: %w", s, maxBits, strconv.ErrRange)
+	}
+	return mantissa, nil
+}
+
+// ParseEpochNumber reads a base 10 number, which may have a sign and a decimal part, like -12 or 44197.75. The
+// decimal part is kept exactly, up to maxScale digits, so 1600000000.123456 from strace -ttt is 123456 microseconds
+// past the second. A number whose digits do not all fit in an int64, up to 128 bits, is held as a big integer, so
+// 9223372036854775807.5 keeps its .5. Digits from any script are read, see NormalizeDigits.
+func ParseEpochNumber(s string) (EpochNumber, error) {
+	s = NormalizeDigits(s)
+	whole, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, fraction = s[:i], s[i+1:]
+	}
+	for _, r := range fraction {
+		if r < '0' || r > '9' {
+			return EpochNumber{}, fmt.Errorf("Decimal part of %q is not a number", s)
+		}
+	}
+	if len(fraction) > maxScale {
+		fraction = fraction[:maxScale]
+	}
+	mantissa, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err == nil {
+		return EpochNumber{Mantissa: mantissa, Scale: len(fraction)}, nil
+	}
+	if !errors.Is(err, strconv.ErrRange) {
+		return EpochNumber{}, err
+	}
+	// the digits are too many for an int64, so keep every one of them in a big integer.
+	bigMantissa, err := parseBig(s, whole+fraction, 10)
+	if err != nil {
+		return EpochNumber{}, err
+	}
+	return newBigEpochNumber(bigMantissa, len(fraction)), nil
+}
+
+// ParseEpochNumberInBase reads a number written in a base, returning the base it was read in. Only base 10 numbers
+// may have a decimal part. A base of 0 detects the base from a prefix - 0x for hex, 0o for octal, 0b for binary, in
+// either case. Anything else is read as base 10, so unlike strconv.ParseInt a zero-padded number like 01600000000 is
+// not octal. For a base of 2, 8 or 16 the matching prefix is optional, so bare hex like 5f5e1000 can be read with a
+// base of 16, and octal with a leading zero, as in tar headers, with a base of 8. Digits from any script are read, see
+// NormalizeDigits.
+func ParseEpochNumberInBase(s string, base int) (number EpochNumber, detectedBase int, err error) {
+	s = NormalizeDigits(s)
+	sign, digits := "", s
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		sign, digits = digits[:1], digits[1:]
+	}
+	detectedBase = base
+	if prefixBase, ok := basePrefixes[strings.ToLower(prefixOf(digits))]; ok && (base == 0 || base == prefixBase) {
+		detectedBase, digits = prefixBase, digits[2:]
+	} else if base == 0 {
+		detectedBase = 10
+	}
+	if detectedBase == 10 {
+		number, err = ParseEpochNumber(sign + digits)
+		return number, detectedBase, err
+	}
+	// strconv accepts underscores only when it detects the base itself, and the prefix has already been removed.
+	if strings.Contains(digits, "_") {
+		return number, detectedBase, fmt.Errorf("Base %d number %q has an underscore", detectedBase, s)
+	}
+	// strconv would read a second sign, as in 0x-10 or --10, which is not a number.
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		return number, detectedBase, fmt.Errorf("Base %d number %q has a sign in its digits", detectedBase, s)
+	}
+	mantissa, err := strconv.ParseInt(sign+digits, detectedBase, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		bigMantissa, err := parseBig(s, sign+digits, detectedBase)
+		if err != nil {
+			return number, detectedBase, err
+		}
+		return NewBigEpochNumber(bigMantissa), detectedBase, nil
+	}
+	if err != nil {
+		return number, detectedBase, err
+	}
+	return NewEpochNumber(mantissa), detectedBase, nil
+}
+
+// basePrefixes maps the lower case prefix of a number to the base it marks.
+var basePrefixes = map[string]int{"0x": 16, "0o": 8, "0b": 2}
+
+func prefixOf(digits string) string {
+	if len(digits) < 2 {
+		return ""
+	}
+	return digits[:2]
+}
+
+// IsInteger reports whether the number has no decimal part.
+func (n EpochNumber) IsInteger() bool {
+	return n.Scale == 0
+}
+
+// Int64 returns the whole part of the number, truncated toward zero like a conversion from float. Numbers too large
+// for an int64 are clamped to math.MaxInt64 or math.MinInt64, see IsInt64.
+func (n EpochNumber) Int64() int64 {
+	if n.big != nil {
+		whole := n.BigInt()
+		if !whole.IsInt64() {
+			if whole.Sign() < 0 {
+				return math.MinInt64
+			}
+			return math.MaxInt64
+		}
+		return whole.Int64()
+	}
+	return n.Mantissa / pow10(n.Scale)
+}
+
+// IsInt64 reports whether the whole part of the number fits in an int64, so Int64 returns it exactly.
+func (n EpochNumber) IsInt64() bool {
+	return n.big == nil || n.BigInt().IsInt64()
+}
+
+// BigInt returns the whole part of the number as a big integer, truncated toward zero.
+func (n EpochNumber) BigInt() *big.Int {
+	return new(big.Int).Quo(n.BigMantissa(), bigPow10(n.Scale))
+}
+
+// BigMantissa returns the mantissa as a big integer, whether or not it fits in Mantissa.
+func (n EpochNumber) BigMantissa() *big.Int {
+	if n.big != nil {
+		return new(big.Int).Set(n.big)
+	}
+	return big.NewInt(n.Mantissa)
+}
+
+// bits64 returns the 64 bits of a whole number which fits in an int64 or a uint64, as they would be stored.
+func (n EpochNumber) bits64() (bits uint64, ok bool) {
+	switch {
+	case !n.IsInteger():
+		return 0, false
+	case n.big == nil:
+		return uint64(n.Mantissa), true
+	case n.big.IsUint64():
+		return n.big.Uint64(), true
+	}
+	return 0, false
+}
+
+// Precision is the smallest step the number can express when counting the unit, given the digits after its decimal
+// point. A whole number of milliseconds is precise to a millisecond, 1600000000.123456 seconds to a microsecond, and
+// 44197.75 days to 864 seconds. It is never finer than a nanosecond, the precision of time.Time.
+func (n EpochNumber) Precision(unit EpochUnit) time.Duration {
+	precision := unit.nanoseconds() / pow10(n.Scale)
+	if precision < 1 {
+		precision = 1
+	}
+	return time.Duration(precision)
+}
+
+// addWhole returns the number with a whole number added to it.
+func (n EpochNumber) addWhole(whole int64) EpochNumber {
+	mantissa := bigPow10(n.Scale)
+	mantissa.Mul(mantissa, big.NewInt(whole))
+	return newBigEpochNumber(mantissa.Add(mantissa, n.BigMantissa()), n.Scale)
+}
+
+// Cmp compares the number with m exactly, returning -1 when it is less than m, 0 when they are equal and +1 when it
+// is greater, whatever their Scale. So 1.50 and 1.5 are equal.
+func (n EpochNumber) Cmp(m EpochNumber) int {
+	a, b := n.alignedWith(m)
+	return a.Cmp(b)
+}
+
+// sub returns the number less m, exactly.
+func (n EpochNumber) sub(m EpochNumber) EpochNumber {
+	a, b := n.alignedWith(m)
+	scale := n.Scale
+	if m.Scale > scale {
+		scale = m.Scale
+	}
+	return newBigEpochNumber(a.Sub(a, b), scale)
+}
+
+// abs returns the size of the number, without its sign.
+func (n EpochNumber) abs() EpochNumber {
+	mantissa := n.BigMantissa()
+	return newBigEpochNumber(mantissa.Abs(mantissa), n.Scale)
+}
+
+// float64 returns the float64 nearest the number, for measures which need not be exact.
+func (n EpochNumber) float64() float64 {
+	f, _ := new(big.Rat).SetFrac(n.BigMantissa(), bigPow10(n.Scale)).Float64()
+	return f
+}
+
+// alignedWith returns the mantissas of the number and m, counted at the larger of their two Scales.
+func (n EpochNumber) alignedWith(m EpochNumber) (a, b *big.Int) {
+	a, b = n.BigMantissa(), m.BigMantissa()
+	if n.Scale < m.Scale {
+		a.Mul(a, bigPow10(m.Scale-n.Scale))
+	} else {
+		b.Mul(b, bigPow10(n.Scale-m.Scale))
+	}
+	return a, b
+}
+
+// bigPow10 returns 10^exponent as a new big integer.
+func bigPow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
+
+// String satisfies the Stringer interface, so this is printed when %s is used in a formatting string for this type.
+func (n EpochNumber) String() string {
+	digits := strconv.FormatInt(n.Mantissa, 10)
+	if n.big != nil {
+		digits = n.big.String()
+	}
+	if n.Scale == 0 {
+		return digits
+	}
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= n.Scale {
+		digits = strings.Repeat("0", n.Scale-len(digits)+1) + digits
+	}
+	point := len(digits) - n.Scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// MarshalText satisfies encoding.TextMarshaler, so numbers are written to JSON exactly as a decimal string.
+func (n EpochNumber) MarshalText() ([]byte, error) {
+	return []byte(n.String()), nil
+}
+
+// UnmarshalText satisfies encoding.TextUnmarshaler, the reverse of MarshalText.
+func (n *EpochNumber) UnmarshalText(text []byte) error {
+	parsed, err := ParseEpochNumber(string(text))
+	if err != nil {
+		return err
+	}
+	*n = parsed
+	return nil
+}
+
+func pow10(exponent int) int64 {
+	result := int64(1)
+	for i := 0; i < exponent; i++ {
+		result *= 10
+	}
+	return result
+}
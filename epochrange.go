@@ -0,0 +1,61 @@
+package epochconv
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Holds the exact arithmetic used to move between numbers and dates, and the range of dates it supports.
+
+// The supported dates are those with a four digit year, which is what RFC 3339 and JSON output can represent.
+var (
+	MinSupportedDate = time.Date(0, time.January, 1, 0, 0, 0, 0, time.UTC)
+	MaxSupportedDate = time.Date(9999, time.December, 31, 23, 59, 59, 999999999, time.UTC)
+)
+
+// ErrOutOfRange is returned, wrapped with the details, when a number falls outside the supported dates in an epoch,
+// or a date cannot be counted in an int64 of an epoch's unit. Check for it with errors.Is.
+var ErrOutOfRange = errors.New("Out of supported range")
+
+var (
+	bigBillion       = big.NewInt(1e9)
+	minSupportedUnix = big.NewInt(MinSupportedDate.Unix())
+	maxSupportedUnix = big.NewInt(MaxSupportedDate.Unix())
+)
+
+// dateForCount returns the date that mantissa/10^scale units after the start of the epoch falls on, rounded down to
+// the nanosecond. The arithmetic is done on big integers, so no count in any unit can wrap around.
+func (e *EpochType) dateForCount(mantissa *big.Int, scale int) (time.Time, error) {
+	nanoseconds := new(big.Int).Mul(mantissa, big.NewInt(e.Unit.nanoseconds()))
+	// Div rounds toward negative infinity for a positive divisor, so dates before the epoch round down as well.
+	nanoseconds.Div(nanoseconds, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+	nanoseconds.Add(nanoseconds, big.NewInt(int64(e.EpochDate.Nanosecond())))
+	seconds, remainder := new(big.Int).DivMod(nanoseconds, bigBillion, new(big.Int))
+	seconds.Add(seconds, big.NewInt(e.EpochDate.Unix()))
+	if seconds.Cmp(minSupportedUnix) < 0 || seconds.Cmp(maxSupportedUnix) > 0 {
+		return time.Time{}, fmt.Errorf("%s in %s since %s: %w", formatCount(mantissa, scale), e.Unit.effective(),
+			e.EpochName, ErrOutOfRange)
+	}
+	return time.Unix(seconds.Int64(), remainder.Int64()).UTC(), nil
+}
+
+// countForDate returns the whole units between the start of the epoch and the date, rounded down.
+func (e *EpochType) countForDate(date time.Time) *big.Int {
+	nanoseconds := big.NewInt(date.Unix() - e.EpochDate.Unix())
+	nanoseconds.Mul(nanoseconds, bigBillion)
+	nanoseconds.Add(nanoseconds, big.NewInt(int64(date.Nanosecond()-e.EpochDate.Nanosecond())))
+	return nanoseconds.Div(nanoseconds, big.NewInt(e.Unit.nanoseconds()))
+}
+
+// formatCount writes mantissa/10^scale as a decimal, for error messages.
+func formatCount(mantissa *big.Int, scale int) string {
+	if mantissa.IsInt64() {
+		return EpochNumber{Mantissa: mantissa.Int64(), Scale: scale}.String()
+	}
+	if scale == 0 {
+		return mantissa.String()
+	}
+	return fmt.Sprintf("%se-%d", mantissa, scale)
+}
@@ -0,0 +1,276 @@
+package epochconv
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Holds the ways the interpretations of a number are ranked against each other.
+
+// Candidate is one interpretation of an input number: an epoch, counted in its unit and encoding, and the date the
+// number gives in it.
+type Candidate struct {
+	EpochType EpochType
+	Value     EpochNumber // The input number
+	Date      time.Time   // The date the number gives in the epoch, in UTC
+	Now       time.Time   // The clock's time, which is later than the reference when ranking near a past hint
+}
+
+// now is the clock's time, or the reference when it is not known.
+func (c Candidate) now(reference time.Time) time.Time {
+	if c.Now.IsZero() {
+		return reference
+	}
+	return c.Now
+}
+
+// Ranker scores the candidates for a number, so they can be ranked most likely first. A Ranker must be safe to call
+// from several goroutines at once. The built in Rankers are NearestToNow, MostRecentPast, PriorWeighted and
+// NearestTo a hint, which may also be picked by name with RankerByName.
+type Ranker interface {
+	// Score returns a weight of zero or more for how likely the candidate is, compared with the other candidates for
+	// the same number. Higher is more likely, and zero rules the candidate out. reference is the time guesses are
+	// made relative to, normally now, or the time nearest to the candidate in a period given with WithNearPeriod.
+	Score(c Candidate, reference time.Time) float64
+}
+
+// Names of the built in Rankers, for RankerByName.
+const (
+	RankerNearest = "nearest"
+	RankerPast    = "past"
+	RankerPrior   = "prior"
+	RankerHint    = "hint"
+)
+
+// RankerNames lists the names RankerByName accepts.
+var RankerNames = []string{RankerNearest, RankerPast, RankerPrior, RankerHint}
+
+// RankerByName returns the built in Ranker with the name: nearest for NearestToNow, past for MostRecentPast, prior for
+// PriorWeighted, or hint for NearestTo the hint. The hint is only needed, and must not be zero, for hint.
+func RankerByName(name string, hint time.Time) (Ranker, error) {
+	switch name {
+	case RankerNearest:
+		return NearestToNow, nil
+	case RankerPast:
+		return MostRecentPast, nil
+	case RankerPrior:
+		return PriorWeighted, nil
+	case RankerHint:
+		if hint.IsZero() {
+			return nil, fmt.Errorf("Ranker %q needs a date to rank near", name)
+		}
+		return NearestTo(hint), nil
+	}
+	return nil, fmt.Errorf("Unknown ranker %q, expected one of %s", name, strings.Join(RankerNames, ", "))
+}
+
+// NearestToNow ranks candidates by distance alone. Candidates whose date is closest to the reference time, before or
+// after it, score highest.
+var NearestToNow Ranker = nearestToNow{}
+
+type nearestToNow struct{}
+
+// Score satisfies the Ranker interface, falling from 1 for a date at the reference time toward 0 as it gets further
+// away.
+func (nearestToNow) Score(c Candidate, reference time.Time) float64 {
+	return 1 / (1 + daysBetween(c.Date, reference))
+}
+
+// daysBetween is the distance between two dates in days, whichever comes first. Unix seconds are subtracted rather
+// than using time.Sub, which is limited to 292 years.
+func daysBetween(a, b time.Time) float64 {
+	seconds := a.Unix() - b.Unix()
+	if seconds < 0 {
+		seconds = -seconds
+	}
+	return float64(seconds) / secondsPerDay
+}
+
+const secondsPerDay = 86400
+
+// MostRecentPast ranks candidates by how close their date is to the reference time, ruling out those dated in the
+// future, as for numbers read from logs and file times which cannot be. Without a hint, the reference time is now,
+// so the most recent past date ranks first.
+var MostRecentPast Ranker = mostRecentPast{}
+
+type mostRecentPast struct{}
+
+// Score satisfies the Ranker interface, scoring dates after now zero.
+func (mostRecentPast) Score(c Candidate, reference time.Time) float64 {
+	if c.Date.After(c.now(reference)) {
+		return 0
+	}
+	return 1 / (1 + daysBetween(c.Date, reference))
+}
+
+// NearestTo returns a Ranker which ranks candidates by distance from the hint, rather than the reference time. Use it
+// when roughly when the numbers were written is known, such as the date of an incident.
+func NearestTo(hint time.Time) Ranker {
+	return nearestTo{hint: hint}
+}
+
+type nearestTo struct {
+	hint time.Time
+}
+
+// Score satisfies the Ranker interface, ignoring the reference time in favour of the hint.
+func (n nearestTo) Score(c Candidate, _ time.Time) float64 {
+	return NearestToNow.Score(c, n.hint)
+}
+
+// PriorWeighted is the Ranker used when none is given. It scores each candidate by how likely its epoch and unit are
+// to be seen at all, times how likely its date is given the reference time: the Prevalence of the epoch, how common
+// numbers counted in its unit are, whether the unit is one of the epoch's CommonUnits, and how far the date is from
+// the reference. So 637000000000000000 is .NET ticks in 2019 rather than Mac OS X nanoseconds in 2021. Dates fall off in likelihood with
+// each year away, and four times as fast when they are after the reference time and in the future, since most
+// numbers record things that have already happened. So 1600000000 is Unix seconds in 2020 rather than FAT seconds in
+// 2030, even in 2026, while epochs whose dates are only days apart, like FAT and GPS, are told apart by their
+// Prevalence.
+var PriorWeighted Ranker = priorWeighted{}
+
+type priorWeighted struct{}
+
+// Score satisfies the Ranker interface.
+func (priorWeighted) Score(c Candidate, reference time.Time) float64 {
+	years := daysBetween(c.Date, reference) / daysPerYear
+	if c.Date.After(reference) && c.Date.After(c.now(reference)) {
+		years *= futureWeight
+	}
+	return c.EpochType.prior() / (1 + years)
+}
+
+const (
+	daysPerYear    = 365.2425
+	futureWeight   = 4
+	rareUnitWeight = 0.1
+)
+
+// prior is how likely a number is to be counted since the epoch in its unit at all, whatever its date.
+func (e EpochType) prior() float64 {
+	prior := prevalencePrior(e.Prevalence) * unitPriors[e.Unit.effective()]
+	if !e.isCommonUnit(e.Unit.effective()) {
+		prior *= rareUnitWeight
+	}
+	return prior
+}
+
+// isCommonUnit reports whether numbers since the epoch are commonly counted in the unit. Every unit is, when the
+// epoch has no CommonUnits.
+func (e EpochType) isCommonUnit(unit EpochUnit) bool {
+	if len(e.CommonUnits) == 0 {
+		return true
+	}
+	for _, common := range e.CommonUnits {
+		if common.effective() == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// prevalencePrior maps a Prevalence from 0 to 5 onto a weight from 1/6 to 1.
+func prevalencePrior(prevalence int) float64 {
+	if prevalence < 0 {
+		prevalence = 0
+	} else if prevalence > 5 {
+		prevalence = 5
+	}
+	return float64(1+prevalence) / 6
+}
+
+// unitPriors weighs how commonly numbers are counted in each unit. Seconds are the most common, then milliseconds
+// from Java and JavaScript. Epochs bound to ticks or days only have those units, so they are not held back.
+var unitPriors = map[EpochUnit]float64{
+	UnitSeconds:      1,
+	UnitMilliseconds: 0.9,
+	UnitMicroseconds: 0.5,
+	UnitNanoseconds:  0.5,
+	UnitTicks:        0.5,
+	UnitDays:         1,
+}
+
+// period is the span of time numbers are ranked against: the clock's time, or the hint given with WithNear or
+// WithNearPeriod.
+type period struct {
+	start, end time.Time
+}
+
+// nearest is the time in the period nearest to the date, which is the date itself when it is in the period.
+func (p period) nearest(date time.Time) time.Time {
+	switch {
+	case date.Before(p.start):
+		return p.start
+	case date.After(p.end):
+		return p.end
+	}
+	return date
+}
+
+// middle is the time halfway through the period.
+func (p period) middle() time.Time {
+	return p.start.Add(p.end.Sub(p.start) / 2)
+}
+
+// rankResults sorts the results by the ranker's score, highest first, and returns their epochs in that order. Each
+// result is scored against the time in the reference period nearest to its date. The Confidence of each result is its
+// share of the total score. Results that score the same are ordered by which epoch started first, and results the
+// ranker rules out are removed.
+func rankResults(ranker Ranker, results []epochResult, reference period, now time.Time) (ranked []epochResult,
+	ecOut EpochCollection) {
+	total := 0.0
+	for _, er := range results {
+		score := ranker.Score(Candidate{EpochType: er.EpochType, Value: er.InputValue, Date: er.DateInEpochUTC,
+			Now: now}, reference.nearest(er.DateInEpochUTC))
+		if score <= 0 || math.IsNaN(score) {
+			continue
+		}
+		er.Confidence = score
+		ranked = append(ranked, er)
+		total += score
+	}
+	for i := range ranked {
+		ranked[i].Confidence /= total
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Confidence != ranked[j].Confidence {
+			return ranked[i].Confidence > ranked[j].Confidence
+		}
+		return ranked[i].EpochType.EpochDate.Before(ranked[j].EpochType.EpochDate)
+	})
+	return ranked, epochsOf(ranked)
+}
+
+// rankSmallCounts ranks results which are all small counts from the start of their epochs, whose dates say nothing
+// of when they were written, by how common their epoch and unit are alone. Of equally common epochs, the one which
+// started latest ranks first, so -86400 is the day before Unix started rather than the day before Windows did.
+func rankSmallCounts(results []epochResult, reference period, now time.Time) (ranked []epochResult,
+	ecOut EpochCollection) {
+	ranked, _ = rankResults(epochPrior{}, results, reference, now)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Confidence != ranked[j].Confidence {
+			return ranked[i].Confidence > ranked[j].Confidence
+		}
+		return ranked[i].EpochType.EpochDate.After(ranked[j].EpochType.EpochDate)
+	})
+	return ranked, epochsOf(ranked)
+}
+
+// epochPrior scores candidates by the prior of their epoch and unit, whatever their date.
+type epochPrior struct{}
+
+// Score satisfies the Ranker interface.
+func (epochPrior) Score(c Candidate, _ time.Time) float64 {
+	return c.EpochType.prior()
+}
+
+// epochsOf is the epochs of the results, in their order.
+func epochsOf(results []epochResult) EpochCollection {
+	ecOut := make(EpochCollection, len(results))
+	for i, er := range results {
+		ecOut[i] = er.EpochType
+	}
+	return ecOut
+}
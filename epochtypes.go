@@ -3,6 +3,7 @@ package epochconv
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"strings"
 	"time"
 )
@@ -14,8 +15,8 @@ import (
 // follow this format.
 const CustomEpochTimeFormatString = "2006-01-02T15:04:05Z"
 
-// Dates which conform to the above formatting string. These must, of course, all be in the past - or there is an
-// outside risk the program would crash due to all numbers being positive.
+// Dates which conform to the above formatting string. Numbers count forward from these dates, and negative numbers
+// count back from them, so dates before an epoch starts are converted too.
 const (
 	dateStringCommonEra      = "0001-01-01T00:00:00Z"
 	dateStringUnixEpoch      = "1970-01-01T00:00:00Z"
@@ -25,6 +26,7 @@ const (
 	dateStringMicrosoftExcel = "1899-12-31T00:00:00Z"
 	dateStringNTP            = "1900-01-01T00:00:00Z"
 	dateStringMacClassic     = "1904-01-01T00:00:00Z"
+	dateStringExcel1904      = "1904-01-01T00:00:00Z"
 	dateStringMicrosoftFAT   = "1980-01-01T00:00:00Z"
 	dateStringGPS            = "1980-01-06T00:00:00Z"
 	dateStringPostgreSQL     = "2000-01-01T00:00:00Z"
@@ -35,13 +37,16 @@ type EpochCollection []EpochType
 
 // Skeletal type
 type EpochType struct {
-	EpochName                   string    `json:"epoch_name"` // Friendly name of epoch
-	EpochUses                   []string  `json:"epoch_uses"` // Slice of common uses of this specific epoch
-	EpochDateString             string    `json:"-"`          // The date string formatted like CustomEpochTimeFormatString that defines this
-	EpochDate                   time.Time `json:"epoch_date"` // The time.Time date representation of the epoch start
-	LocalRightNowInSecondsSince int64     `json:"now_local"`  // time.Now().Local - Local time in seconds since epoch start.
-	UTCRightNowInSecondsSince   int64     `json:"now_utc"`    // time.Now().UTC - UTC time in seconds since epoch start.
-	Prevalence                  int       `json:"prevalence"` // 0-5, 0 being least common. Helps decide most likely matches when it's close.
+	EpochName                   string         `json:"epoch_name"`             // Friendly name of epoch
+	EpochUses                   []string       `json:"epoch_uses"`             // Slice of common uses of this specific epoch
+	EpochDateString             string         `json:"-"`                      // The date string formatted like CustomEpochTimeFormatString that defines this
+	EpochDate                   time.Time      `json:"epoch_date"`             // The time.Time date representation of the epoch start
+	LocalRightNowInSecondsSince int64          `json:"now_local"`              // time.Now().Local - Local time in seconds since epoch start.
+	UTCRightNowInSecondsSince   int64          `json:"now_utc"`                // time.Now().UTC - UTC time in seconds since epoch start.
+	Prevalence                  int            `json:"prevalence"`             // 0-5, 0 being least common. Helps decide most likely matches when it's close.
+	Unit                        EpochUnit      `json:"unit"`                   // Resolution numbers are counted in. Unspecified is evaluated at every DefaultUnits.
+	Encoding                    NumberEncoding `json:"encoding"`               // How the count is packed into a number, plain for nearly all epochs.
+	CommonUnits                 []EpochUnit    `json:"common_units,omitempty"` // Units numbers are commonly counted in. Others are ranked as rare, unless empty.
 }
 
 var (
@@ -63,6 +68,7 @@ var (
 		LocalRightNowInSecondsSince: te(dateStringUnixEpoch, false),
 		UTCRightNowInSecondsSince:   te(dateStringUnixEpoch, true),
 		Prevalence:                  5,
+		CommonUnits:                 []EpochUnit{UnitSeconds, UnitMilliseconds, UnitMicroseconds, UnitNanoseconds},
 	}
 
 	EpochWindowsEpoch = EpochType{
@@ -73,6 +79,44 @@ var (
 		LocalRightNowInSecondsSince: te(dateStringWindowsEpoch, false),
 		UTCRightNowInSecondsSince:   te(dateStringWindowsEpoch, true),
 		Prevalence:                  5,
+		CommonUnits:                 []EpochUnit{UnitSeconds, UnitMicroseconds},
+	}
+
+	// Win32 FILETIME, counted in 100 nanosecond ticks since the Windows epoch.
+	EpochWindowsFileTime = EpochType{
+		EpochName:                   "Windows FILETIME",
+		EpochUses:                   []string{"Win32 FILETIME", "NTFS timestamps", "Active Directory", "Windows event logs", "Windows registry"},
+		EpochDateString:             dateStringWindowsEpoch,
+		EpochDate:                   sp(dateStringWindowsEpoch),
+		LocalRightNowInSecondsSince: te(dateStringWindowsEpoch, false),
+		UTCRightNowInSecondsSince:   te(dateStringWindowsEpoch, true),
+		Prevalence:                  5,
+		Unit:                        UnitTicks,
+	}
+
+	// .NET DateTime.Ticks, counted in 100 nanosecond ticks since the start of the common era.
+	EpochDotNetTicks = EpochType{
+		EpochName:                   ".NET DateTime Ticks",
+		EpochUses:                   []string{"Microsoft .NET DateTime.Ticks", "DateTimeOffset.Ticks", "PowerShell Get-Date Ticks"},
+		EpochDateString:             dateStringCommonEra,
+		EpochDate:                   sp(dateStringCommonEra),
+		LocalRightNowInSecondsSince: te(dateStringCommonEra, false),
+		UTCRightNowInSecondsSince:   te(dateStringCommonEra, true),
+		Prevalence:                  4,
+		Unit:                        UnitTicks,
+	}
+
+	// .NET DateTime.ToBinary, ticks since the start of the common era with the DateTimeKind in the top two bits.
+	EpochDotNetBinary = EpochType{
+		EpochName:                   ".NET DateTime Binary",
+		EpochUses:                   []string{"Microsoft .NET DateTime.ToBinary", "DateTime.FromBinary"},
+		EpochDateString:             dateStringCommonEra,
+		EpochDate:                   sp(dateStringCommonEra),
+		LocalRightNowInSecondsSince: te(dateStringCommonEra, false),
+		UTCRightNowInSecondsSince:   te(dateStringCommonEra, true),
+		Prevalence:                  3,
+		Unit:                        UnitTicks,
+		Encoding:                    EncodingDateTimeBinary,
 	}
 
 	EpochVMS = EpochType{
@@ -83,8 +127,10 @@ var (
 		LocalRightNowInSecondsSince: te(dateStringVMSEpoch, false),
 		UTCRightNowInSecondsSince:   te(dateStringVMSEpoch, true),
 		Prevalence:                  3,
+		CommonUnits:                 []EpochUnit{UnitSeconds, UnitTicks},
 	}
 
+	// Serial days, the decimal part being the time of day. Day 1 is 1899-12-31, and there is no leap year bug.
 	EpochMicrosoftCOM = EpochType{
 		EpochName:                   "Microsoft COM",
 		EpochUses:                   []string{"Microsoft COM DATE", "Object Pascal", "LibreOffice Calc", "Google Sheets", "Technical internal value used by Microsoft Excel"},
@@ -93,8 +139,11 @@ var (
 		LocalRightNowInSecondsSince: te(dateStringMicrosoftCOM, false),
 		UTCRightNowInSecondsSince:   te(dateStringMicrosoftCOM, true),
 		Prevalence:                  4,
+		Unit:                        UnitDays,
 	}
 
+	// Serial days in the default 1900 date system. Day 1 is 1900-01-01, and day 60 is the 1900-02-29 that Excel
+	// believes in, so from 1900-03-01 on these agree with Microsoft COM.
 	EpochMicrosoftExcel = EpochType{
 		EpochName:                   "Microsoft Excel",
 		EpochUses:                   []string{"Microsoft Excel", "Lotus 1-2-3"},
@@ -103,6 +152,24 @@ var (
 		LocalRightNowInSecondsSince: te(dateStringMicrosoftExcel, false),
 		UTCRightNowInSecondsSince:   te(dateStringMicrosoftExcel, true),
 		Prevalence:                  3,
+		Unit:                        UnitDays,
+		Encoding:                    EncodingExcel1900,
+	}
+
+	// Serial days in the 1904 date system, an option in Excel and the default in old Excel for Mac. It is far less used
+	// than the 1900 system, whose dates are four years earlier, so 44197.75 is 2021 in that rather than 2025 in this.
+	// Its Prevalence is 0 so that PriorWeighted never ranks it before the 1900 system and Microsoft COM, which give
+	// every serial the same earlier date; any more would take serials from the last year or two as 1904 dates. It
+	// still ranks first with NearestToNow, or in a collection without them.
+	EpochMicrosoftExcel1904 = EpochType{
+		EpochName:                   "Microsoft Excel 1904",
+		EpochUses:                   []string{"Microsoft Excel 1904 date system", "Excel for Mac 2011 and earlier"},
+		EpochDateString:             dateStringExcel1904,
+		EpochDate:                   sp(dateStringExcel1904),
+		LocalRightNowInSecondsSince: te(dateStringExcel1904, false),
+		UTCRightNowInSecondsSince:   te(dateStringExcel1904, true),
+		Prevalence:                  0,
+		Unit:                        UnitDays,
 	}
 
 	EpochNTP = EpochType{
@@ -113,6 +180,7 @@ var (
 		LocalRightNowInSecondsSince: te(dateStringNTP, false),
 		UTCRightNowInSecondsSince:   te(dateStringNTP, true),
 		Prevalence:                  2,
+		CommonUnits:                 []EpochUnit{UnitSeconds},
 	}
 
 	EpochMacClassic = EpochType{
@@ -123,8 +191,11 @@ var (
 		LocalRightNowInSecondsSince: te(dateStringMacClassic, false),
 		UTCRightNowInSecondsSince:   te(dateStringMacClassic, true),
 		Prevalence:                  2,
+		CommonUnits:                 []EpochUnit{UnitSeconds},
 	}
 
+	// FAT file times are packed dates and times, so a count of seconds since 1980 is rare. Were it as common as Unix, a
+	// Unix date in 2010 would be taken for a FAT date in 2020, ten years nearer now.
 	EpochFAT = EpochType{
 		EpochName:                   "FAT",
 		EpochUses:                   []string{"FAT12", "FAT16", "FAT32", "exFAT filesystems", "IBM BIOS", "INT 1Ah", "DOS", "OS/2"},
@@ -132,10 +203,12 @@ var (
 		EpochDate:                   sp(dateStringMicrosoftFAT),
 		LocalRightNowInSecondsSince: te(dateStringMicrosoftFAT, false),
 		UTCRightNowInSecondsSince:   te(dateStringMicrosoftFAT, true),
-		Prevalence:                  5,
+		Prevalence:                  1,
+		CommonUnits:                 []EpochUnit{UnitSeconds},
 	}
 
-	// This is very close to FAT
+	// This is very close to FAT, and like it a count of seconds is rare, as GPS receivers count weeks and seconds into
+	// the week.
 	EpochGPS = EpochType{
 		EpochName:                   "GPS",
 		EpochUses:                   []string{"Qualcomm BREW", "GPS", "ATSC 32-bit time stamps"},
@@ -143,7 +216,8 @@ var (
 		EpochDate:                   sp(dateStringGPS),
 		LocalRightNowInSecondsSince: te(dateStringGPS, false),
 		UTCRightNowInSecondsSince:   te(dateStringGPS, true),
-		Prevalence:                  2,
+		Prevalence:                  1,
+		CommonUnits:                 []EpochUnit{UnitSeconds},
 	}
 	// This epoch is very close to OS X epoch
 	EpochPostgreSQL = EpochType{
@@ -154,6 +228,7 @@ var (
 		LocalRightNowInSecondsSince: te(dateStringPostgreSQL, false),
 		UTCRightNowInSecondsSince:   te(dateStringPostgreSQL, true),
 		Prevalence:                  3,
+		CommonUnits:                 []EpochUnit{UnitSeconds, UnitMicroseconds},
 	}
 
 	EpochMacOSX = EpochType{
@@ -163,10 +238,12 @@ var (
 		EpochDate:                   sp(dateStringMacOSX),
 		LocalRightNowInSecondsSince: te(dateStringMacOSX, false),
 		UTCRightNowInSecondsSince:   te(dateStringMacOSX, true),
-		Prevalence:                  5,
+		Prevalence:                  4,
+		CommonUnits:                 []EpochUnit{UnitSeconds},
 	}
-	AllEpochs = EpochCollection{EpochCommonEra, EpochWindowsEpoch, EpochVMS, EpochMicrosoftCOM, EpochMicrosoftExcel,
-		EpochNTP, EpochMacClassic, EpochUnix, EpochFAT, EpochGPS, EpochPostgreSQL, EpochMacOSX}
+	AllEpochs = EpochCollection{EpochCommonEra, EpochDotNetTicks, EpochDotNetBinary, EpochWindowsEpoch,
+		EpochWindowsFileTime, EpochVMS, EpochMicrosoftCOM, EpochMicrosoftExcel, EpochMicrosoftExcel1904, EpochNTP,
+		EpochMacClassic, EpochUnix, EpochFAT, EpochGPS, EpochPostgreSQL, EpochMacOSX}
 )
 
 // GuessesForStrings is a method on any EpochCollection, which can be constructed to pick and choose relevant or custom
@@ -174,24 +251,85 @@ var (
 // Given a slice of strings, return an EpochGuessResults type, which is an array of EpochResults along with the most
 // likely result. Strings in the input slice are parsed in the following way:
 // 1) Strings are stripped of leading and trailing whitespace characters.
-// 2) Strings have all data after the first dot character removed. This allows for input of decimal numbers
-//    Without needing to convert to floats.
+// 2) Strings with a 0x, 0o or 0b prefix are read as hex, octal or binary. A leading zero alone does not make octal.
+// 3) Other strings are read as base 10 numbers, which may have a decimal part after a dot. The decimal part is kept
+//    exactly, without converting to floats.
+// 4) Any of them may have a sign. Negative numbers are dates before the epoch starts.
 // If one string cannot be converted, an Error is created indicating at least one string could not be converted. These strings
 // are returned in the badStrings slice.
 // This can, of course, be ignored - and may be in a typical use case.
 func (ec EpochCollection) GuessesForStrings(stringsToConvert []string) (epochResults []EpochResults, badStrings []string, err error) {
-	epochResults, badStrings, err = createGuesses(stringsToConvert, ec)
+	epochResults, badStrings, _, err = NewGuesser(WithCollection(ec)).GuessStrings(stringsToConvert)
+	return epochResults, badStrings, err
+}
+
+// GuessesForStringsInBase is a method on any EpochCollection. It is EpochCollection.GuessesForStrings for numbers
+// written in a base, see ParseEpochNumberInBase.
+func (ec EpochCollection) GuessesForStringsInBase(stringsToConvert []string, base int) (epochResults []EpochResults,
+	badStrings []string, err error) {
+	epochResults, badStrings, _, err = NewGuesser(WithCollection(ec), WithBase(base)).GuessStrings(
+		stringsToConvert)
+	return epochResults, badStrings, err
+}
+
+// GuessesForStringsInZones is a method on any EpochCollection. It is EpochCollection.GuessesForStringsInBase with
+// each date also given in each of the zones, in DatesInZones. Load zones by IANA name with time.LoadLocation.
+func (ec EpochCollection) GuessesForStringsInZones(stringsToConvert []string, base int, zones []*time.Location) (
+	epochResults []EpochResults, badStrings []string, err error) {
+	epochResults, badStrings, _, err = NewGuesser(WithCollection(ec), WithBase(base),
+		WithZones(zones...)).GuessStrings(stringsToConvert)
+	return epochResults, badStrings, err
+}
+
+// GuessesForStringsAt is a method on any EpochCollection. It is EpochCollection.GuessesForStringsInZones with numbers
+// ranked by how close they are to the clock's time, rather than the current time. The RightNow fields of the
+// returned EpochTypes are counted to the clock's time as well. A nil clock is the SystemClock.
+func (ec EpochCollection) GuessesForStringsAt(stringsToConvert []string, base int, zones []*time.Location,
+	clock Clock) (epochResults []EpochResults, badStrings []string, err error) {
+	epochResults, badStrings, _, err = NewGuesser(WithCollection(ec), WithBase(base), WithZones(zones...),
+		WithClock(clock)).GuessStrings(stringsToConvert)
 	return epochResults, badStrings, err
 }
 
+// AtTime is a method on an EpochCollection. It returns a copy of the collection with the RightNow fields of each
+// EpochType counted to the given time, see EpochType.AtTime.
+func (ec EpochCollection) AtTime(now time.Time) (ecOut EpochCollection) {
+	ecOut = make(EpochCollection, len(ec))
+	for i, et := range ec {
+		ecOut[i] = et.AtTime(now)
+	}
+	return ecOut
+}
+
 // String satisfies the Stringer interface, so this is printed when %s is used in a formatting string for this type.
 func (e EpochType) String() string {
 	return fmt.Sprintf("Name of Epoch: %s\n"+
+		"Unit: %s\n"+
+		"Encoding: %s\n"+
 		"Used for: %s\n"+
 		"Started On (UTC): %s\n"+
 		"Current UTC Time in Epoch Seconds: %d\n"+
-		"Current Local Time in Epoch Seconds: %d\n", e.EpochName, strings.Join(e.EpochUses, ", "),
-		e.EpochDate.Format(time.RFC3339), e.UTCRightNowInSecondsSince, e.LocalRightNowInSecondsSince)
+		"Current Local Time in Epoch Seconds: %d\n", e.EpochName, e.Unit.effective(), e.Encoding,
+		strings.Join(e.EpochUses, ", "), e.EpochDate.Format(time.RFC3339), e.UTCRightNowInSecondsSince,
+		e.LocalRightNowInSecondsSince)
+}
+
+// AtTime is a method on an EpochType. It returns a copy with LocalRightNowInSecondsSince and
+// UTCRightNowInSecondsSince counted to the given time. The fields of the package's epochs are counted when the package
+// is loaded, so they are stale in a long running process.
+func (e EpochType) AtTime(now time.Time) EpochType {
+	e.UTCRightNowInSecondsSince = secondsSinceEpochStart(e.EpochDate, now, true)
+	e.LocalRightNowInSecondsSince = secondsSinceEpochStart(e.EpochDate, now, false)
+	return e
+}
+
+// Label is a method on an EpochType. It names the epoch along with its unit, and its encoding when that is not
+// plain, like "Unix, milliseconds" or "Unix, seconds, byte-swapped, little-endian 32-bit".
+func (e EpochType) Label() string {
+	if e.Encoding == EncodingPlain {
+		return fmt.Sprintf("%s, %s", e.EpochName, e.Unit.effective())
+	}
+	return fmt.Sprintf("%s, %s, %s", e.EpochName, e.Unit.effective(), e.Encoding)
 }
 
 // String satisfies the Stringer interface, so this is printed when %s is used in a formatting string for this type.
@@ -229,9 +367,12 @@ func (a ByEpochDate) Swap(i, j int) {
 	a[i], a[j] = a[j], a[i]
 }
 func (a ByEpochDate) Less(i, j int) bool {
-	return a[i].EpochDate.Second() < a[j].EpochDate.Second()
+	return a[i].EpochDate.Before(a[j].EpochDate)
 }
 
+// ByNearestDate sorts by the second of the minute each epoch started on.
+//
+// Deprecated: it does not order by nearness. Use EpochCollection.OrderedEpochsByRanker.
 type ByNearestDate EpochCollection
 
 func (a ByNearestDate) Len() int {
@@ -244,21 +385,103 @@ func (a ByNearestDate) Less(i, j int) bool {
 	return a[i].EpochDate.Second() < a[j].EpochDate.Second()
 }
 
-// DateForNumber is a method on an EpochType. Given a number (in seconds), return the date (as time.Time) for the epoch
-func (e *EpochType) DateForNumber(epochSeconds int64, utcFlag bool) (timeInEpoch time.Time) {
+// DateForNumber is a method on an EpochType. Given a number (in the epoch's Unit), return the date (as time.Time) for
+// the epoch. See DateForValue.
+func (e *EpochType) DateForNumber(number int64, utcFlag bool) (timeInEpoch time.Time, err error) {
+	return e.DateForValue(NewEpochNumber(number), utcFlag)
+}
+
+// DateForNumberIn is a method on an EpochType. It is DateForNumber with the date in a given location.
+func (e *EpochType) DateForNumberIn(number int64, loc *time.Location) (timeInEpoch time.Time, err error) {
+	return e.DateForValueIn(NewEpochNumber(number), loc)
+}
+
+// DateForValue is a method on an EpochType. Given a number (in the epoch's Unit) which may have a decimal part,
+// return the date (as time.Time) for the epoch, in UTC or in the local time zone. See DateForValueIn.
+func (e *EpochType) DateForValue(value EpochNumber, utcFlag bool) (timeInEpoch time.Time, err error) {
+	return e.DateForValueIn(value, utcOrLocal(utcFlag))
+}
+
+// DateForValueIn is a method on an EpochType. Given a number (in the epoch's Unit) which may have a decimal part,
+// return the date (as time.Time) for the epoch in the given location. The number is first unpacked according to the
+// epoch's Encoding, then counted forward from the epoch start exactly, to the nanosecond, so any number in any unit,
+// however wide, converts without wrapping. The decimal part of a day is kept as the time of day. The location's
+// offset is the one in force on that date, so daylight saving time and historical changes of offset are honoured.
+// Negative numbers are dates before the epoch starts, such as -86400 Unix seconds on 1969-12-31. A date outside
+// MinSupportedDate and MaxSupportedDate returns an error wrapping ErrOutOfRange, and a number the Encoding cannot
+// unpack returns an error wrapping ErrWrongEncoding.
+func (e *EpochType) DateForValueIn(value EpochNumber, loc *time.Location) (timeInEpoch time.Time, err error) {
+	value, err = e.Encoding.decode(value)
+	if err != nil {
+		return timeInEpoch, err
+	}
+	return e.dateForCountIn(value.BigMantissa(), value.Scale, loc)
+}
+
+// DateForBigNumber is a method on an EpochType. It is DateForNumber for whole numbers of any size, such as unsigned
+// 64 bit counters or 128 bit fields.
+func (e *EpochType) DateForBigNumber(number *big.Int, utcFlag bool) (timeInEpoch time.Time, err error) {
+	return e.DateForBigNumberIn(number, utcOrLocal(utcFlag))
+}
+
+// DateForBigNumberIn is a method on an EpochType. It is DateForBigNumber with the date in a given location.
+func (e *EpochType) DateForBigNumberIn(number *big.Int, loc *time.Location) (timeInEpoch time.Time, err error) {
+	if number.IsInt64() {
+		return e.DateForNumberIn(number.Int64(), loc)
+	}
+	switch e.Encoding {
+	case EncodingDateTimeBinary, EncodingByteSwapped32, EncodingByteSwapped64:
+		return timeInEpoch, fmt.Errorf("%s is wider than 64 bits for %s: %w", number, e.Encoding, ErrWrongEncoding)
+	case EncodingExcel1900:
+		if number.Sign() > 0 {
+			number = new(big.Int).Sub(number, big.NewInt(1))
+		}
+	}
+	return e.dateForCountIn(number, 0, loc)
+}
+
+func (e *EpochType) dateForCountIn(mantissa *big.Int, scale int, loc *time.Location) (timeInEpoch time.Time, err error) {
+	timeInEpoch, err = e.dateForCount(mantissa, scale)
+	if err != nil {
+		return timeInEpoch, err
+	}
+	timeInEpoch = timeInEpoch.In(loc)
+	// the year in the location must still have four digits
+	if year := timeInEpoch.Year(); year < MinSupportedDate.Year() || year > MaxSupportedDate.Year() {
+		return time.Time{}, fmt.Errorf("%s in %s: %w", formatCount(mantissa, scale), loc, ErrOutOfRange)
+	}
+	return timeInEpoch, err
+}
+
+// utcOrLocal is the location a utcFlag stands for.
+func utcOrLocal(utcFlag bool) *time.Location {
 	if utcFlag {
-		timeInEpoch = e.EpochDate.Add(time.Second * time.Duration(epochSeconds))
-	} else {
-		// local time
-		_, offsetSeconds := time.Now().In(time.Local).Zone()
-		timeInEpoch = e.EpochDate.Add(time.Second * time.Duration(epochSeconds+int64(offsetSeconds)))
+		return time.UTC
 	}
-	return timeInEpoch
+	return time.Local
 }
 
-// NumberForDate is a method on an EpochType. Given a date (as time.Time), return the seconds since that epoch.
-func (e *EpochType) NumberForDate(date time.Time) int64 {
-	return int64(date.Sub(e.EpochDate).Seconds())
+// NumberForDate is a method on an EpochType. Given a date (as time.Time), return the number of the epoch's Unit
+// since that epoch, rounded down. Units longer than a second are counted whole, so the time of day is dropped from
+// days. A count that does not fit in an int64, like nanoseconds since the common era, returns an error wrapping
+// ErrOutOfRange; use BigNumberForDate for those.
+func (e *EpochType) NumberForDate(date time.Time) (number int64, err error) {
+	count := e.BigNumberForDate(date)
+	if !count.IsInt64() {
+		return 0, fmt.Errorf("%s is %s %s since %s: %w", date.Format(time.RFC3339Nano), count, e.Unit.effective(),
+			e.EpochName, ErrOutOfRange)
+	}
+	return count.Int64(), nil
+}
+
+// BigNumberForDate is a method on an EpochType. It is NumberForDate without the int64 limit.
+func (e *EpochType) BigNumberForDate(date time.Time) *big.Int {
+	count := e.countForDate(date)
+	// the inverse of EncodingExcel1900, skipping over the phantom leap day
+	if e.Encoding == EncodingExcel1900 && count.Cmp(big.NewInt(excelPhantomLeapDay)) >= 0 {
+		count.Add(count, big.NewInt(1))
+	}
+	return count
 }
 
 // secondsForEpochString returns a specific date in the epoch const formatting string.
@@ -268,17 +491,20 @@ func secondsForEpochString(epochConstFormatString string, specificTime time.Time
 	if err != nil {
 		return 0, err
 	}
-	var dur int64
-	if utcFlag {
-		dur = int64(specificTime.UTC().Sub(epochStart).Seconds())
-	} else {
-		dur = int64(specificTime.Local().Sub(epochStart).Seconds())
-	}
+	return secondsSinceEpochStart(epochStart, specificTime, utcFlag), err
+}
+
+// secondsSinceEpochStart returns the whole seconds from the start of an epoch to a specific time, on the UTC or the
+// local wall clock.
+func secondsSinceEpochStart(epochStart time.Time, specificTime time.Time, utcFlag bool) int64 {
+	// Unix seconds are subtracted rather than using time.Sub, which is limited to 292 years.
+	dur := specificTime.Unix() - epochStart.Unix()
 	if !utcFlag {
-		_, offsetSeconds := time.Now().In(time.Local).Zone()
+		// the wall clock in the local time zone, with the offset in force at that time.
+		_, offsetSeconds := specificTime.In(time.Local).Zone()
 		dur += int64(offsetSeconds)
 	}
-	return dur, err
+	return dur
 }
 
 // sp and te functions are used only for initializing a struct literal, which can only handle a single return value.
@@ -0,0 +1,153 @@
+package epochconv
+
+import (
+	"fmt"
+)
+
+// Holds the resolutions an epoch number may be counted in.
+
+// EpochUnit is the resolution a number is counted in since the start of an epoch. JavaScript and Java count
+// milliseconds since the Unix epoch, Go and many databases count nanoseconds, Windows counts 100 nanosecond ticks.
+type EpochUnit int
+
+// The zero value, UnitUnspecified, behaves like UnitSeconds when converting. It marks an EpochType as not being bound
+// to any one resolution, so EpochCollection.AtResolutions will evaluate it at every requested unit.
+const (
+	UnitUnspecified EpochUnit = iota
+	UnitSeconds
+	UnitMilliseconds
+	UnitMicroseconds
+	UnitNanoseconds
+	UnitTicks // 100 nanosecond intervals
+	UnitDays  // Serial day numbers, as in spreadsheets, where the decimal part is the time of day
+)
+
+// DefaultUnits are the resolutions every unbound EpochType is evaluated at when guessing.
+var DefaultUnits = []EpochUnit{UnitSeconds, UnitMilliseconds, UnitMicroseconds, UnitNanoseconds, UnitTicks}
+
+// unitNames holds the names used for printing, and for marshalling to and from JSON.
+var unitNames = map[EpochUnit]string{
+	UnitUnspecified:  "unspecified",
+	UnitSeconds:      "seconds",
+	UnitMilliseconds: "milliseconds",
+	UnitMicroseconds: "microseconds",
+	UnitNanoseconds:  "nanoseconds",
+	UnitTicks:        "ticks",
+	UnitDays:         "days",
+}
+
+// String satisfies the Stringer interface, so this is printed when %s is used in a formatting string for this type.
+func (u EpochUnit) String() string {
+	if name, ok := unitNames[u]; ok {
+		return name
+	}
+	return fmt.Sprintf("EpochUnit(%d)", int(u))
+}
+
+// MarshalText satisfies encoding.TextMarshaler, so units are readable in JSON output.
+func (u EpochUnit) MarshalText() ([]byte, error) {
+	if _, ok := unitNames[u]; !ok {
+		return nil, fmt.Errorf("Unknown epoch unit %d", int(u))
+	}
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText satisfies encoding.TextUnmarshaler, the reverse of MarshalText.
+func (u *EpochUnit) UnmarshalText(text []byte) error {
+	unit, err := ParseEpochUnit(string(text))
+	if err != nil {
+		return err
+	}
+	*u = unit
+	return nil
+}
+
+// ParseEpochUnit returns the unit for a name as printed by EpochUnit.String.
+func ParseEpochUnit(name string) (EpochUnit, error) {
+	for unit, unitName := range unitNames {
+		if unitName == name {
+			return unit, nil
+		}
+	}
+	return UnitUnspecified, fmt.Errorf("Unknown epoch unit %q", name)
+}
+
+// effective maps UnitUnspecified to UnitSeconds, which is how unbound epochs are converted.
+func (u EpochUnit) effective() EpochUnit {
+	if u == UnitUnspecified {
+		return UnitSeconds
+	}
+	return u
+}
+
+// perSecond is the number of units in one second, which is 0 for units longer than a second.
+func (u EpochUnit) perSecond() int64 {
+	return int64(1e9) / u.nanoseconds()
+}
+
+// seconds is the length of one unit in whole seconds, which is 0 for units shorter than a second.
+func (u EpochUnit) seconds() int64 {
+	return u.nanoseconds() / 1e9
+}
+
+// nanoseconds is the length of one unit in nanoseconds.
+func (u EpochUnit) nanoseconds() int64 {
+	switch u.effective() {
+	case UnitMilliseconds:
+		return 1e6
+	case UnitMicroseconds:
+		return 1e3
+	case UnitNanoseconds:
+		return 1
+	case UnitTicks:
+		return 100
+	case UnitDays:
+		return 86400 * 1e9
+	default:
+		return 1e9
+	}
+}
+
+// AtResolutions is a method on an EpochCollection. Every EpochType that is not bound to a unit is copied once for
+// each of the given units, so each copy is converted and ranked at that resolution. EpochTypes already bound to a
+// unit are kept as they are, and no copy is made that would duplicate one of them - so Windows in ticks is left to
+// Windows FILETIME. The original collection is not altered.
+func (ec EpochCollection) AtResolutions(units ...EpochUnit) (ecOut EpochCollection) {
+	bound := make(map[string]bool)
+	for _, et := range ec {
+		if et.Unit != UnitUnspecified {
+			bound[resolutionKey(et.EpochDate.Unix(), et.Unit, et.Encoding)] = true
+		}
+	}
+	for _, et := range ec {
+		if et.Unit != UnitUnspecified {
+			ecOut = append(ecOut, et)
+			continue
+		}
+		for _, unit := range units {
+			if bound[resolutionKey(et.EpochDate.Unix(), unit.effective(), et.Encoding)] {
+				continue
+			}
+			atUnit := et
+			atUnit.Unit = unit.effective()
+			ecOut = append(ecOut, atUnit)
+		}
+	}
+	return ecOut
+}
+
+func resolutionKey(epochStart int64, unit EpochUnit, encoding NumberEncoding) string {
+	return fmt.Sprintf("%d/%s/%s", epochStart, unit, encoding)
+}
+
+// units is a method on an EpochCollection. It lists each unit the epochs are counted in once, in the order first seen.
+func (ec EpochCollection) units() (units []EpochUnit) {
+	seen := make(map[EpochUnit]bool)
+	for _, et := range ec {
+		if unit := et.Unit.effective(); !seen[unit] {
+			seen[unit] = true
+			units = append(units, unit)
+		}
+	}
+	return units
+}
@@ -0,0 +1,110 @@
+package epochconv
+
+import (
+	"fmt"
+	"time"
+)
+
+// Holds the verdict on whether a number is a timestamp at all.
+
+// Verdict says whether a number is likely to be a timestamp at all, rather than a count, an ID or a phone number
+// which happens to have a date in some epoch.
+type Verdict int
+
+const (
+	VerdictTimestamp    Verdict = iota // The most likely date is within the plausible window
+	VerdictUnlikely                    // The most likely date is not far outside the plausible window, or a rare reading
+	VerdictNotTimestamp                // The most likely date is far from the plausible window, or at its epoch's start
+)
+
+// verdictNames holds the names used for printing, and for marshalling to and from JSON.
+var verdictNames = map[Verdict]string{
+	VerdictTimestamp:    "timestamp",
+	VerdictUnlikely:     "unlikely",
+	VerdictNotTimestamp: "not a timestamp",
+}
+
+// String satisfies the Stringer interface, so this is printed when %s is used in a formatting string for this type.
+func (v Verdict) String() string {
+	if name, ok := verdictNames[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("Verdict(%d)", int(v))
+}
+
+// MarshalText satisfies encoding.TextMarshaler, so verdicts are readable in JSON output.
+func (v Verdict) MarshalText() ([]byte, error) {
+	if _, ok := verdictNames[v]; !ok {
+		return nil, fmt.Errorf("Unknown verdict %d", int(v))
+	}
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText satisfies encoding.TextUnmarshaler, the reverse of MarshalText.
+func (v *Verdict) UnmarshalText(text []byte) error {
+	for verdict, name := range verdictNames {
+		if name == string(text) {
+			*v = verdict
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown verdict %q", string(text))
+}
+
+// The plausible window runs from plausibleYearsBefore the reference time to plausibleYearsAfter it, unless a window is
+// given with WithWindow. Dates up to unlikelyYears outside it are unlikely, and dates further out are not timestamps.
+const (
+	plausibleYearsBefore = 50
+	plausibleYearsAfter  = 20
+	unlikelyYears        = 50
+	commonPrevalence     = 4 // Epochs less prevalent than this rarely have dates in the future
+)
+
+// verdictFor judges whether the result is a timestamp, by how many years its date is outside the plausible window
+// around the reference time. A date within a day of its epoch's start, such as 42 in any epoch, is a small count
+// rather than a timestamp. A date in the window is still unlikely when the number only gets there in a unit its epoch
+// is rarely counted in, or as a future date in a less common epoch, as phone numbers like 5551234567 do in VMS.
+func (c guessConfig) verdictFor(er epochResult, reference, now time.Time) (verdict Verdict, reason string) {
+	if er.isSmallCount() {
+		return VerdictNotTimestamp, fmt.Sprintf("%s is within a day of the start of %s", er.DateInEpochUTC.Format(
+			time.RFC3339), er.EpochType.EpochName)
+	}
+	after, before := c.after, c.before
+	if after.IsZero() {
+		after = reference.AddDate(-plausibleYearsBefore, 0, 0)
+	}
+	if before.IsZero() {
+		before = reference.AddDate(plausibleYearsAfter, 0, 0)
+	}
+	years := 0.0
+	switch {
+	case er.DateInEpochUTC.Before(after):
+		years = daysBetween(er.DateInEpochUTC, after) / daysPerYear
+	case er.DateInEpochUTC.After(before):
+		years = daysBetween(er.DateInEpochUTC, before) / daysPerYear
+	default:
+		reason = fmt.Sprintf("%s is from %d to %d", er.DateInEpochUTC.Format(time.RFC3339), after.Year(), before.Year())
+		if !er.EpochType.isCommonUnit(er.Unit) {
+			return VerdictUnlikely, fmt.Sprintf("%s, but only in %s %s, which are rare", reason, er.EpochType.EpochName,
+				er.Unit)
+		}
+		if er.DateInEpochUTC.After(reference) && er.DateInEpochUTC.After(now) &&
+			er.EpochType.Prevalence < commonPrevalence {
+			return VerdictUnlikely, fmt.Sprintf("%s, but in the future in %s, which is rare", reason,
+				er.EpochType.EpochName)
+		}
+		return VerdictTimestamp, reason
+	}
+	reason = fmt.Sprintf("%s is %.1f years outside %d to %d", er.DateInEpochUTC.Format(time.RFC3339), years,
+		after.Year(), before.Year())
+	if years <= unlikelyYears {
+		return VerdictUnlikely, reason
+	}
+	return VerdictNotTimestamp, reason
+}
+
+// isSmallCount reports whether the date is at most a day from the start of its epoch, before or after it, as 42 and
+// -86400 are in any epoch. Such a number is a small count rather than a timestamp.
+func (er epochResult) isSmallCount() bool {
+	return absInt64(er.DateInEpochUTC.Unix()-er.EpochType.EpochDate.Unix()) <= secondsPerDay
+}